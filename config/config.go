@@ -3,14 +3,44 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+// RateLimitRule is a token bucket refilling Requests tokens every Period and
+// holding at most Burst. Zero Requests disables it.
+type RateLimitRule struct {
+	Requests int           `mapstructure:"requests"`
+	Period   time.Duration `mapstructure:"period"`
+	Burst    int           `mapstructure:"burst"`
+}
+
+type RateLimitRoute struct {
+	PerIP RateLimitRule `mapstructure:"per_ip"`
+	// PerIdentifier is keyed by the email in the request body.
+	PerIdentifier RateLimitRule `mapstructure:"per_identifier"`
+}
+
+// LockoutPolicy delays logins after FreeAttempts failures, doubling from
+// BaseDelay up to MaxDelay, and locks for LockoutDuration after LockoutAfter.
+type LockoutPolicy struct {
+	FreeAttempts    int           `mapstructure:"free_attempts"`
+	BaseDelay       time.Duration `mapstructure:"base_delay"`
+	MaxDelay        time.Duration `mapstructure:"max_delay"`
+	LockoutAfter    int           `mapstructure:"lockout_after"`
+	LockoutDuration time.Duration `mapstructure:"lockout_duration"`
+	ResetAfter      time.Duration `mapstructure:"reset_after"`
+}
+
 type Config struct {
 	AuthService struct {
 		HTTP struct {
 			Port int `mapstructure:"port"`
+			// TrustedProxies are the addresses or CIDRs allowed to set
+			// X-Forwarded-For. Empty trusts none, so client IPs used for rate
+			// limits and lockouts can't be spoofed.
+			TrustedProxies []string `mapstructure:"trusted_proxies"`
 		} `mapstructure:"http"`
 		AWS struct {
 			AccessKey       string `mapstructure:"access_key"`
@@ -20,11 +50,126 @@ type Config struct {
 			Region     string `mapstructure:"region"`
 			UserPoolId string `mapstructure:"user_pool_id"`
 			ClientId   string `mapstructure:"client_id"`
+			// ClientSecret is only set for app clients created with a secret.
+			ClientSecret string `mapstructure:"client_secret"`
+			// AuthFlow is USER_PASSWORD_AUTH or USER_SRP_AUTH.
+			AuthFlow string `mapstructure:"auth_flow"`
 		} `mapstructure:"cognito"`
 		JWT struct {
-			PublicKey string `mapstructure:"public_key"`
+			PublicKey          string        `mapstructure:"public_key"`
+			RefreshInterval    time.Duration `mapstructure:"refresh_interval"`
+			MinRefreshInterval time.Duration `mapstructure:"min_refresh_interval"`
+			CacheFile          string        `mapstructure:"cache_file"`
+			Issuer             string        `mapstructure:"issuer"`
+			Audience           []string      `mapstructure:"audience"`
+			TokenUse           string        `mapstructure:"token_use"`
+			Leeway             time.Duration `mapstructure:"leeway"`
 		} `mapstructure:"jwt"`
-	} `mapstructure: "authService"`
+		OAuth struct {
+			// Domain is the user pool domain serving the hosted UI; empty disables the OAuth endpoints.
+			Domain       string        `mapstructure:"domain"`
+			RedirectURIs []string      `mapstructure:"redirect_uris"`
+			Scopes       []string      `mapstructure:"scopes"`
+			StateTTL     time.Duration `mapstructure:"state_ttl"`
+			// CookieSecret seals the state cookie and must be the same on every
+			// replica; empty uses a random key per process.
+			CookieSecret string `mapstructure:"cookie_secret"`
+		} `mapstructure:"oauth"`
+		Profile struct {
+			// Attributes /me returns and accepts; empty lists use the defaults.
+			ReadableAttributes []string `mapstructure:"readable_attributes"`
+			WritableAttributes []string `mapstructure:"writable_attributes"`
+		} `mapstructure:"profile"`
+		Deletion struct {
+			GracePeriod time.Duration `mapstructure:"grace_period"`
+			// StateFile keeps pending deletions across restarts; empty keeps them in memory only.
+			StateFile string `mapstructure:"state_file"`
+		} `mapstructure:"deletion"`
+		Admin struct {
+			// Group is the Cognito group whose members may use /admin.
+			Group string `mapstructure:"group"`
+		} `mapstructure:"admin"`
+		MFA struct {
+			Issuer string `mapstructure:"issuer"`
+		} `mapstructure:"mfa"`
+		IDP struct {
+			Provider string `mapstructure:"provider"`
+			// MemoryAdmins are emails that join the admin group when they sign up
+			// with the memory provider.
+			MemoryAdmins []string `mapstructure:"memory_admins"`
+			// MemoryInvites are emails the memory provider creates at startup as if
+			// invited by an admin: the temporary password is logged and must be
+			// replaced at the first login.
+			MemoryInvites []string `mapstructure:"memory_invites"`
+		} `mapstructure:"idp"`
+		// PasswordPolicy is enforced by the memory provider and checked before any
+		// call to Cognito, so it should mirror the user pool's policy.
+		PasswordPolicy struct {
+			MinimumLength    int  `mapstructure:"minimum_length"`
+			RequireLowercase bool `mapstructure:"require_lowercase"`
+			RequireUppercase bool `mapstructure:"require_uppercase"`
+			RequireNumbers   bool `mapstructure:"require_numbers"`
+			RequireSymbols   bool `mapstructure:"require_symbols"`
+			// MinScore is the lowest strength score, 0 to 4, a new password may have.
+			MinScore int `mapstructure:"min_score"`
+			// BreachedList is a file of breached passwords or their SHA-1 hashes,
+			// one per line; empty skips the check.
+			BreachedList string `mapstructure:"breached_list"`
+		} `mapstructure:"password_policy"`
+		RateLimit struct {
+			Enabled bool `mapstructure:"enabled"`
+			// Store is "memory" or "redis"; only redis shares limits between replicas.
+			Store          string         `mapstructure:"store"`
+			RedisURL       string         `mapstructure:"redis_url"`
+			Login          RateLimitRoute `mapstructure:"login"`
+			Signup         RateLimitRoute `mapstructure:"signup"`
+			ForgotPassword RateLimitRoute `mapstructure:"forgot_password"`
+			ResendConfirm  RateLimitRoute `mapstructure:"resend_confirm"`
+			Challenge      RateLimitRoute `mapstructure:"challenge"`
+			Refresh        RateLimitRoute `mapstructure:"refresh"`
+			ConfirmForgot  RateLimitRoute `mapstructure:"confirm_forgot"`
+			EmailChange    RateLimitRoute `mapstructure:"email_change"`
+		} `mapstructure:"rate_limit"`
+		Lockout struct {
+			Enabled  bool          `mapstructure:"enabled"`
+			PerEmail LockoutPolicy `mapstructure:"per_email"`
+			PerIP    LockoutPolicy `mapstructure:"per_ip"`
+			// NotifyAfter failures for one email, NotifyWebhook receives the email and
+			// failure record so the owner can be told. Without a webhook it is only logged.
+			NotifyAfter   int    `mapstructure:"notify_after"`
+			NotifyWebhook string `mapstructure:"notify_webhook"`
+		} `mapstructure:"lockout"`
+		Audit struct {
+			Enabled bool `mapstructure:"enabled"`
+			Stdout  bool `mapstructure:"stdout"`
+			// File receives JSON lines, rotated once it reaches MaxSizeMB.
+			File       string `mapstructure:"file"`
+			MaxSizeMB  int    `mapstructure:"max_size_mb"`
+			MaxBackups int    `mapstructure:"max_backups"`
+			Webhook    string `mapstructure:"webhook"`
+			// WebhookBuffer events wait for the webhook before new ones are dropped.
+			WebhookBuffer int `mapstructure:"webhook_buffer"`
+			// Secret keys the HMAC chain; audit-verify needs the same one.
+			Secret string `mapstructure:"secret"`
+			// CheckpointInterval is how often the head of the chain goes to
+			// stdout and the webhook, so truncating the file is detected.
+			CheckpointInterval time.Duration `mapstructure:"checkpoint_interval"`
+		} `mapstructure:"audit"`
+		Metrics struct {
+			Enabled bool `mapstructure:"enabled"`
+			// Address of the internal listener serving /metrics, e.g. ":9090".
+			// Keep it off the public port.
+			Address string `mapstructure:"address"`
+		} `mapstructure:"metrics"`
+		Tracing struct {
+			// Exporter is "otlp" or "stdout"; empty disables tracing.
+			Exporter string `mapstructure:"exporter"`
+			// Endpoint is the OTLP/HTTP collector URL, e.g. http://otel-collector:4318.
+			Endpoint    string  `mapstructure:"endpoint"`
+			ServiceName string  `mapstructure:"service_name"`
+			SampleRatio float64 `mapstructure:"sample_ratio"`
+		} `mapstructure:"tracing"`
+	} `mapstructure:"authService"`
 }
 
 func LoadConfig(filePath string) (*Config, error) {
@@ -53,13 +198,65 @@ func LoadConfig(filePath string) (*Config, error) {
 	viper.SetEnvPrefix("APP")
 
 	// Bind specific environment variables to struct fields
-	viper.BindEnv("AuthService.HTTP.Port", "APP_HTTP_PORT")
-	viper.BindEnv("AuthService.AWS.AccessKey", "APP_AWS_ACCESS_KEY")
-	viper.BindEnv("AuthService.AWS.SecretAccessKey", "APP_AWS_SECRET_ACCESS_KEY")
-	viper.BindEnv("AuthService.Cognito.Region", "APP_COGNITO_REGION")
-	viper.BindEnv("AuthService.Cognito.UserPoolId", "APP_COGNITO_USER_POOL_ID")
-	viper.BindEnv("AuthService.Cognito.ClientId", "APP_COGNITO_CLIENT_ID")
-	viper.BindEnv("AuthService.JWT.PublicKey", "APP_JWT_PUBLIC_KEY")
+	viper.BindEnv("authService.http.port", "APP_HTTP_PORT")
+	viper.BindEnv("authService.http.trusted_proxies", "APP_HTTP_TRUSTED_PROXIES")
+	viper.BindEnv("authService.aws.access_key", "APP_AWS_ACCESS_KEY")
+	viper.BindEnv("authService.aws.secret_access_key", "APP_AWS_SECRET_ACCESS_KEY")
+	viper.BindEnv("authService.cognito.region", "APP_COGNITO_REGION")
+	viper.BindEnv("authService.cognito.user_pool_id", "APP_COGNITO_USER_POOL_ID")
+	viper.BindEnv("authService.cognito.client_id", "APP_COGNITO_CLIENT_ID")
+	viper.BindEnv("authService.cognito.client_secret", "APP_COGNITO_CLIENT_SECRET")
+	viper.BindEnv("authService.cognito.auth_flow", "APP_COGNITO_AUTH_FLOW")
+	viper.BindEnv("authService.jwt.public_key", "APP_JWT_PUBLIC_KEY")
+	viper.BindEnv("authService.jwt.refresh_interval", "APP_JWT_REFRESH_INTERVAL")
+	viper.BindEnv("authService.jwt.min_refresh_interval", "APP_JWT_MIN_REFRESH_INTERVAL")
+	viper.BindEnv("authService.jwt.cache_file", "APP_JWT_CACHE_FILE")
+	viper.BindEnv("authService.jwt.issuer", "APP_JWT_ISSUER")
+	viper.BindEnv("authService.jwt.audience", "APP_JWT_AUDIENCE")
+	viper.BindEnv("authService.jwt.token_use", "APP_JWT_TOKEN_USE")
+	viper.BindEnv("authService.jwt.leeway", "APP_JWT_LEEWAY")
+	viper.BindEnv("authService.oauth.domain", "APP_OAUTH_DOMAIN")
+	viper.BindEnv("authService.oauth.redirect_uris", "APP_OAUTH_REDIRECT_URIS")
+	viper.BindEnv("authService.oauth.scopes", "APP_OAUTH_SCOPES")
+	viper.BindEnv("authService.oauth.state_ttl", "APP_OAUTH_STATE_TTL")
+	viper.BindEnv("authService.oauth.cookie_secret", "APP_OAUTH_COOKIE_SECRET")
+	viper.BindEnv("authService.profile.readable_attributes", "APP_PROFILE_READABLE_ATTRIBUTES")
+	viper.BindEnv("authService.profile.writable_attributes", "APP_PROFILE_WRITABLE_ATTRIBUTES")
+	viper.BindEnv("authService.deletion.grace_period", "APP_DELETION_GRACE_PERIOD")
+	viper.BindEnv("authService.deletion.state_file", "APP_DELETION_STATE_FILE")
+	viper.BindEnv("authService.admin.group", "APP_ADMIN_GROUP")
+	viper.BindEnv("authService.mfa.issuer", "APP_MFA_ISSUER")
+	viper.BindEnv("authService.idp.provider", "APP_IDP_PROVIDER")
+	viper.BindEnv("authService.idp.memory_admins", "APP_IDP_MEMORY_ADMINS")
+	viper.BindEnv("authService.idp.memory_invites", "APP_IDP_MEMORY_INVITES")
+	viper.BindEnv("authService.password_policy.minimum_length", "APP_PASSWORD_POLICY_MINIMUM_LENGTH")
+	viper.BindEnv("authService.password_policy.require_lowercase", "APP_PASSWORD_POLICY_REQUIRE_LOWERCASE")
+	viper.BindEnv("authService.password_policy.require_uppercase", "APP_PASSWORD_POLICY_REQUIRE_UPPERCASE")
+	viper.BindEnv("authService.password_policy.require_numbers", "APP_PASSWORD_POLICY_REQUIRE_NUMBERS")
+	viper.BindEnv("authService.password_policy.require_symbols", "APP_PASSWORD_POLICY_REQUIRE_SYMBOLS")
+	viper.BindEnv("authService.password_policy.min_score", "APP_PASSWORD_POLICY_MIN_SCORE")
+	viper.BindEnv("authService.password_policy.breached_list", "APP_PASSWORD_POLICY_BREACHED_LIST")
+	viper.BindEnv("authService.rate_limit.enabled", "APP_RATE_LIMIT_ENABLED")
+	viper.BindEnv("authService.rate_limit.store", "APP_RATE_LIMIT_STORE")
+	viper.BindEnv("authService.rate_limit.redis_url", "APP_RATE_LIMIT_REDIS_URL")
+	viper.BindEnv("authService.lockout.enabled", "APP_LOCKOUT_ENABLED")
+	viper.BindEnv("authService.lockout.notify_after", "APP_LOCKOUT_NOTIFY_AFTER")
+	viper.BindEnv("authService.lockout.notify_webhook", "APP_LOCKOUT_NOTIFY_WEBHOOK")
+	viper.BindEnv("authService.audit.enabled", "APP_AUDIT_ENABLED")
+	viper.BindEnv("authService.audit.stdout", "APP_AUDIT_STDOUT")
+	viper.BindEnv("authService.audit.file", "APP_AUDIT_FILE")
+	viper.BindEnv("authService.audit.max_size_mb", "APP_AUDIT_MAX_SIZE_MB")
+	viper.BindEnv("authService.audit.max_backups", "APP_AUDIT_MAX_BACKUPS")
+	viper.BindEnv("authService.audit.webhook", "APP_AUDIT_WEBHOOK")
+	viper.BindEnv("authService.audit.webhook_buffer", "APP_AUDIT_WEBHOOK_BUFFER")
+	viper.BindEnv("authService.audit.secret", "APP_AUDIT_SECRET")
+	viper.BindEnv("authService.audit.checkpoint_interval", "APP_AUDIT_CHECKPOINT_INTERVAL")
+	viper.BindEnv("authService.metrics.enabled", "APP_METRICS_ENABLED")
+	viper.BindEnv("authService.metrics.address", "APP_METRICS_ADDRESS")
+	viper.BindEnv("authService.tracing.exporter", "APP_TRACING_EXPORTER")
+	viper.BindEnv("authService.tracing.endpoint", "APP_TRACING_ENDPOINT")
+	viper.BindEnv("authService.tracing.service_name", "APP_TRACING_SERVICE_NAME")
+	viper.BindEnv("authService.tracing.sample_ratio", "APP_TRACING_SAMPLE_RATIO")
 
 	// Unmarshal the config into the Config struct
 	if err := viper.Unmarshal(&config); err != nil {
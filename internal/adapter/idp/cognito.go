@@ -2,23 +2,46 @@ package idp
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"go.uber.org/zap"
 
 	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
 )
 
 type CognitoAdapter struct {
-	client   *cip.Client
-	poolID   string
-	clientID string
+	client       *cip.Client
+	poolID       string
+	clientID     string
+	clientSecret string
+	authFlow     types.AuthFlowType
+	// emailChange is set when the pool keeps the old email until the new one
+	// is verified; otherwise ChangeEmail is refused.
+	emailChange bool
 }
 
-func NewCognitoAdapter(accessKey, secretAccessKey, poolID, clientID, region string) (*CognitoAdapter, error) {
+// NewCognitoAdapter creates an adapter for the given app client. clientSecret
+// is empty for public clients; when set, every call that identifies a user
+// is signed with a SECRET_HASH. authFlow selects how Login authenticates,
+// USER_PASSWORD_AUTH (the default) or USER_SRP_AUTH. The pool is described
+// once to find out whether ChangeEmail is safe to offer.
+func NewCognitoAdapter(accessKey, secretAccessKey, poolID, clientID, clientSecret, authFlow, region string, logger *zap.Logger) (*CognitoAdapter, error) {
+	flow := types.AuthFlowType(authFlow)
+	switch flow {
+	case "":
+		flow = types.AuthFlowTypeUserPasswordAuth
+	case types.AuthFlowTypeUserPasswordAuth, types.AuthFlowTypeUserSrpAuth:
+	default:
+		return nil, fmt.Errorf("unsupported cognito auth flow %q", authFlow)
+	}
+
 	cfg, err := config.LoadDefaultConfig(context.Background(),
 		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretAccessKey, "")),
 		config.WithRegion(region),
@@ -27,11 +50,61 @@ func NewCognitoAdapter(accessKey, secretAccessKey, poolID, clientID, region stri
 		return nil, err
 	}
 
-	return &CognitoAdapter{
-		client:   cip.NewFromConfig(cfg),
-		poolID:   poolID,
-		clientID: clientID,
-	}, nil
+	adapter := &CognitoAdapter{
+		client: cip.NewFromConfig(cfg, func(o *cip.Options) {
+			o.APIOptions = append(o.APIOptions, instrumentCognito)
+		}),
+		poolID:       poolID,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		authFlow:     flow,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	adapter.emailChange, err = adapter.keepsEmailUntilVerified(ctx)
+	if err != nil {
+		logger.Warn("Failed to describe the user pool, email changes are disabled", zap.Error(err))
+	} else if !adapter.emailChange {
+		logger.Warn("User pool updates email before it is verified, email changes are disabled; require verification before update for email to enable them")
+	}
+
+	return adapter, nil
+}
+
+// keepsEmailUntilVerified reports whether the pool requires a new email to be
+// verified before it replaces the old one. Without that, UpdateUserAttributes
+// makes an unverified address the login alias straight away.
+func (a *CognitoAdapter) keepsEmailUntilVerified(ctx context.Context) (bool, error) {
+	result, err := a.client.DescribeUserPool(ctx, &cip.DescribeUserPoolInput{UserPoolId: aws.String(a.poolID)})
+	if err != nil {
+		return false, err
+	}
+	if result.UserPool == nil || result.UserPool.UserAttributeUpdateSettings == nil {
+		return false, nil
+	}
+	for _, attribute := range result.UserPool.UserAttributeUpdateSettings.AttributesRequireVerificationBeforeUpdate {
+		if attribute == types.VerifiedAttributeTypeEmail {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// secretHash returns the SECRET_HASH for username, or nil for public clients.
+func (a *CognitoAdapter) secretHash(username string) *string {
+	if a.clientSecret == "" {
+		return nil
+	}
+	return aws.String(secretHash(a.clientSecret, a.clientID, username))
+}
+
+// withSecretHash adds SECRET_HASH to InitiateAuth and RespondToAuthChallenge parameters.
+func (a *CognitoAdapter) withSecretHash(parameters map[string]string, username string) map[string]string {
+	if hash := a.secretHash(username); hash != nil {
+		parameters["SECRET_HASH"] = *hash
+	}
+	return parameters
 }
 
 func (a *CognitoAdapter) Register(ctx context.Context, userRegistration entity.UserRegistration) (string, error) {
@@ -50,6 +123,7 @@ func (a *CognitoAdapter) Register(ctx context.Context, userRegistration entity.U
 		ClientId:       aws.String(a.clientID),
 		Username:       aws.String(userRegistration.Email),
 		Password:       aws.String(userRegistration.Password),
+		SecretHash:     a.secretHash(userRegistration.Email),
 		UserAttributes: attributes,
 	}
 
@@ -60,30 +134,158 @@ func (a *CognitoAdapter) Register(ctx context.Context, userRegistration entity.U
 	return *result.CodeDeliveryDetails.Destination, nil
 }
 
-func (a *CognitoAdapter) Login(ctx context.Context, userLogin entity.UserLogin) (*entity.LoginResult, error) {
+func (a *CognitoAdapter) Login(ctx context.Context, userLogin entity.UserLogin) (*entity.AuthResult, error) {
+	if a.authFlow == types.AuthFlowTypeUserSrpAuth {
+		return a.loginSRP(ctx, userLogin)
+	}
+
 	params := &cip.InitiateAuthInput{
-		AuthFlow: "USER_PASSWORD_AUTH",
-		AuthParameters: map[string]string{
+		AuthFlow: types.AuthFlowTypeUserPasswordAuth,
+		AuthParameters: a.withSecretHash(map[string]string{
 			"USERNAME": userLogin.Email,
 			"PASSWORD": userLogin.Password,
-		},
+		}, userLogin.Email),
 		ClientId: aws.String(a.clientID),
 	}
 
 	result, err := a.client.InitiateAuth(ctx, params)
+	if err != nil {
+		return nil, handleLoginError(err)
+	}
+
+	return toAuthResult(result.AuthenticationResult, result.ChallengeName, result.Session, result.ChallengeParameters)
+}
+
+// loginSRP proves knowledge of the password without sending it: InitiateAuth
+// with SRP_A, then answer the PASSWORD_VERIFIER challenge with a signature
+// derived from the password. Any challenge after that is returned as usual.
+func (a *CognitoAdapter) loginSRP(ctx context.Context, userLogin entity.UserLogin) (*entity.AuthResult, error) {
+	session, err := newSRPSession()
+	if err != nil {
+		return nil, err
+	}
+
+	initiated, err := a.client.InitiateAuth(ctx, &cip.InitiateAuthInput{
+		AuthFlow: types.AuthFlowTypeUserSrpAuth,
+		AuthParameters: a.withSecretHash(map[string]string{
+			"USERNAME": userLogin.Email,
+			"SRP_A":    session.SRPA(),
+		}, userLogin.Email),
+		ClientId: aws.String(a.clientID),
+	})
+	if err != nil {
+		return nil, handleLoginError(err)
+	}
+	if initiated.ChallengeName != types.ChallengeNameTypePasswordVerifier {
+		return toAuthResult(initiated.AuthenticationResult, initiated.ChallengeName, initiated.Session, initiated.ChallengeParameters)
+	}
+
+	// Cognito resolves aliases such as the email; the claim is signed over the real username.
+	parameters := initiated.ChallengeParameters
+	userID := parameters["USER_ID_FOR_SRP"]
+	timestamp, signature, err := session.PasswordClaim(a.poolID, userID, userLogin.Password,
+		parameters["SALT"], parameters["SRP_B"], parameters["SECRET_BLOCK"], time.Now())
+	if err != nil {
+		return nil, errUnexpectedAuthResponse
+	}
+
+	result, err := a.client.RespondToAuthChallenge(ctx, &cip.RespondToAuthChallengeInput{
+		ChallengeName: types.ChallengeNameTypePasswordVerifier,
+		ChallengeResponses: a.withSecretHash(map[string]string{
+			"USERNAME":                    userID,
+			"PASSWORD_CLAIM_SECRET_BLOCK": parameters["SECRET_BLOCK"],
+			"PASSWORD_CLAIM_SIGNATURE":    signature,
+			"TIMESTAMP":                   timestamp,
+		}, userID),
+		ClientId: aws.String(a.clientID),
+		Session:  initiated.Session,
+	})
+	if err != nil {
+		return nil, handleLoginError(err)
+	}
+
+	return toAuthResult(result.AuthenticationResult, result.ChallengeName, result.Session, result.ChallengeParameters)
+}
+
+// RespondToChallenge answers as the challenge's USER_ID_FOR_SRP when the
+// client sends it back; after SRP, Cognito rejects the email alias there.
+// A user_id that isn't the account of email is refused.
+func (a *CognitoAdapter) RespondToChallenge(ctx context.Context, challengeResponse entity.ChallengeResponse) (*entity.AuthResult, error) {
+	username := challengeResponse.UserID
+	if username == "" {
+		username = challengeResponse.Email
+	} else if username != challengeResponse.Email {
+		user, err := a.AdminGetUser(ctx, username)
+		if is[*types.UserNotFoundException](err) {
+			return nil, errChallengeUserMismatch
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(user.Attributes["email"], challengeResponse.Email) {
+			return nil, errChallengeUserMismatch
+		}
+	}
+	responses := map[string]string{
+		"USERNAME": username,
+	}
+
+	switch challengeResponse.ChallengeName {
+	case entity.ChallengeNewPasswordRequired:
+		responses["NEW_PASSWORD"] = challengeResponse.NewPassword
+		for name, value := range challengeResponse.Attributes {
+			responses["userAttributes."+name] = value
+		}
+	case entity.ChallengeSMSMFA:
+		responses["SMS_MFA_CODE"] = challengeResponse.Code
+	case entity.ChallengeSoftwareTokenMFA:
+		responses["SOFTWARE_TOKEN_MFA_CODE"] = challengeResponse.Code
+	case entity.ChallengeSelectMFAType:
+		responses["ANSWER"] = challengeResponse.MFAType
+	default:
+		return nil, errUnsupportedChallenge
+	}
+
+	params := &cip.RespondToAuthChallengeInput{
+		ChallengeName:      types.ChallengeNameType(challengeResponse.ChallengeName),
+		ChallengeResponses: a.withSecretHash(responses, username),
+		ClientId:           aws.String(a.clientID),
+		Session:            aws.String(challengeResponse.Session),
+	}
+
+	result, err := a.client.RespondToAuthChallenge(ctx, params)
 	if err != nil {
 		return nil, handleCognitoError(err)
 	}
 
-	loginReturn := entity.LoginResult{
-		AccessToken:  result.AuthenticationResult.AccessToken,
-		ExpiresIn:    &result.AuthenticationResult.ExpiresIn,
-		IdToken:      result.AuthenticationResult.IdToken,
-		RefreshToken: result.AuthenticationResult.RefreshToken,
-		TokenType:    result.AuthenticationResult.TokenType,
+	return toAuthResult(result.AuthenticationResult, result.ChallengeName, result.Session, result.ChallengeParameters)
+}
+
+func (a *CognitoAdapter) RefreshToken(ctx context.Context, refreshToken entity.RefreshToken) (*entity.LoginResult, error) {
+	parameters := map[string]string{
+		"REFRESH_TOKEN": refreshToken.RefreshToken,
+	}
+	if a.clientSecret != "" {
+		// For refreshes Cognito expects the hash over the user's sub rather than the email.
+		sub, ok := subjectFromToken(refreshToken.AccessToken)
+		if !ok {
+			return nil, errRefreshSubjectRequired
+		}
+		a.withSecretHash(parameters, sub)
+	}
+
+	params := &cip.InitiateAuthInput{
+		AuthFlow:       types.AuthFlowTypeRefreshTokenAuth,
+		AuthParameters: parameters,
+		ClientId:       aws.String(a.clientID),
+	}
+
+	result, err := a.client.InitiateAuth(ctx, params)
+	if err != nil {
+		return nil, handleRefreshTokenError(err)
 	}
 
-	return &loginReturn, nil
+	return toLoginResult(result.AuthenticationResult), nil
 }
 
 func (a *CognitoAdapter) ConfirmRegistration(ctx context.Context, userRegistrationConfirm entity.UserRegistrationConfirm) error {
@@ -91,6 +293,7 @@ func (a *CognitoAdapter) ConfirmRegistration(ctx context.Context, userRegistrati
 		ClientId:         aws.String(a.clientID),
 		ConfirmationCode: aws.String(userRegistrationConfirm.ConfirmationCode),
 		Username:         aws.String(userRegistrationConfirm.Email),
+		SecretHash:       a.secretHash(userRegistrationConfirm.Email),
 	}
 
 	_, err := a.client.ConfirmSignUp(ctx, params)
@@ -103,8 +306,9 @@ func (a *CognitoAdapter) ConfirmRegistration(ctx context.Context, userRegistrati
 
 func (a *CognitoAdapter) ResendConfirmationCode(ctx context.Context, email string) (*entity.Email, error) {
 	params := &cip.ResendConfirmationCodeInput{
-		ClientId: aws.String(a.clientID),
-		Username: aws.String(email),
+		ClientId:   aws.String(a.clientID),
+		Username:   aws.String(email),
+		SecretHash: a.secretHash(email),
 	}
 
 	result, err := a.client.ResendConfirmationCode(ctx, params)
@@ -117,8 +321,9 @@ func (a *CognitoAdapter) ResendConfirmationCode(ctx context.Context, email strin
 
 func (a *CognitoAdapter) ForgotPassword(ctx context.Context, email string) (*entity.Email, error) {
 	params := &cip.ForgotPasswordInput{
-		ClientId: aws.String(a.clientID),
-		Username: aws.String(email),
+		ClientId:   aws.String(a.clientID),
+		Username:   aws.String(email),
+		SecretHash: a.secretHash(email),
 	}
 
 	result, err := a.client.ForgotPassword(ctx, params)
@@ -135,6 +340,7 @@ func (a *CognitoAdapter) ConfirmForgotPassword(ctx context.Context, userResetPas
 		Username:         aws.String(userResetPassword.Email),
 		ConfirmationCode: aws.String(userResetPassword.ConfirmationCode),
 		Password:         aws.String(userResetPassword.NewPassword),
+		SecretHash:       a.secretHash(userResetPassword.Email),
 	}
 
 	_, err := a.client.ConfirmForgotPassword(ctx, params)
@@ -159,3 +365,480 @@ func (a *CognitoAdapter) ChangePassword(ctx context.Context, accessToken string,
 
 	return nil
 }
+
+func (a *CognitoAdapter) GlobalSignOut(ctx context.Context, accessToken string) error {
+	params := &cip.GlobalSignOutInput{
+		AccessToken: aws.String(accessToken),
+	}
+
+	_, err := a.client.GlobalSignOut(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) RevokeToken(ctx context.Context, refreshToken string) error {
+	params := &cip.RevokeTokenInput{
+		ClientId: aws.String(a.clientID),
+		Token:    aws.String(refreshToken),
+	}
+	if a.clientSecret != "" {
+		params.ClientSecret = aws.String(a.clientSecret)
+	}
+
+	_, err := a.client.RevokeToken(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) AssociateSoftwareToken(ctx context.Context, accessToken string) (*entity.SoftwareTokenAssociation, error) {
+	user, err := a.client.GetUser(ctx, &cip.GetUserInput{
+		AccessToken: aws.String(accessToken),
+	})
+	if err != nil {
+		return nil, handleCognitoError(err)
+	}
+
+	// Authenticator apps show the account name next to the code; prefer the email over the opaque username.
+	accountName := aws.ToString(user.Username)
+	for _, attribute := range user.UserAttributes {
+		if aws.ToString(attribute.Name) == "email" {
+			accountName = aws.ToString(attribute.Value)
+		}
+	}
+
+	result, err := a.client.AssociateSoftwareToken(ctx, &cip.AssociateSoftwareTokenInput{
+		AccessToken: aws.String(accessToken),
+	})
+	if err != nil {
+		return nil, handleCognitoError(err)
+	}
+
+	return &entity.SoftwareTokenAssociation{
+		SecretCode:  aws.ToString(result.SecretCode),
+		AccountName: accountName,
+	}, nil
+}
+
+func (a *CognitoAdapter) VerifySoftwareToken(ctx context.Context, accessToken string, verifySoftwareToken entity.VerifySoftwareToken) error {
+	params := &cip.VerifySoftwareTokenInput{
+		AccessToken: aws.String(accessToken),
+		UserCode:    aws.String(verifySoftwareToken.Code),
+	}
+	if verifySoftwareToken.DeviceName != "" {
+		params.FriendlyDeviceName = aws.String(verifySoftwareToken.DeviceName)
+	}
+
+	result, err := a.client.VerifySoftwareToken(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+	if result.Status != types.VerifySoftwareTokenResponseTypeSuccess {
+		return errSoftwareTokenMismatch
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) SetMFAPreference(ctx context.Context, accessToken string, mfaPreference entity.MFAPreference) error {
+	params := &cip.SetUserMFAPreferenceInput{
+		AccessToken: aws.String(accessToken),
+	}
+	if mfaPreference.TOTP != nil {
+		params.SoftwareTokenMfaSettings = &types.SoftwareTokenMfaSettingsType{
+			Enabled:      mfaPreference.TOTP.Enabled,
+			PreferredMfa: mfaPreference.TOTP.Preferred,
+		}
+	}
+	if mfaPreference.SMS != nil {
+		params.SMSMfaSettings = &types.SMSMfaSettingsType{
+			Enabled:      mfaPreference.SMS.Enabled,
+			PreferredMfa: mfaPreference.SMS.Preferred,
+		}
+	}
+
+	_, err := a.client.SetUserMFAPreference(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) GetUser(ctx context.Context, accessToken string) (*entity.User, error) {
+	result, err := a.client.GetUser(ctx, &cip.GetUserInput{
+		AccessToken: aws.String(accessToken),
+	})
+	if err != nil {
+		return nil, handleCognitoError(err)
+	}
+
+	return &entity.User{
+		Username:   aws.ToString(result.Username),
+		Attributes: toAttributeMap(result.UserAttributes),
+	}, nil
+}
+
+func (a *CognitoAdapter) UpdateUserAttributes(ctx context.Context, accessToken string, attributes map[string]string) error {
+	params := &cip.UpdateUserAttributesInput{
+		AccessToken: aws.String(accessToken),
+	}
+	for name, value := range attributes {
+		params.UserAttributes = append(params.UserAttributes, types.AttributeType{
+			Name:  aws.String(name),
+			Value: aws.String(value),
+		})
+	}
+
+	_, err := a.client.UpdateUserAttributes(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+// ChangeEmail has Cognito send a code to a new email. It is refused unless
+// the pool keeps the old email as the login alias until the code is verified.
+func (a *CognitoAdapter) ChangeEmail(ctx context.Context, accessToken string, email string) (*entity.Email, error) {
+	if !a.emailChange {
+		return nil, errEmailChangeDisabled
+	}
+
+	result, err := a.client.UpdateUserAttributes(ctx, &cip.UpdateUserAttributesInput{
+		AccessToken: aws.String(accessToken),
+		UserAttributes: []types.AttributeType{
+			{
+				Name:  aws.String("email"),
+				Value: aws.String(email),
+			},
+		},
+	})
+	if err != nil {
+		return nil, handleEmailChangeError(err)
+	}
+	if len(result.CodeDeliveryDetailsList) == 0 {
+		return nil, errUnexpectedAuthResponse
+	}
+
+	return &entity.Email{Email: aws.ToString(result.CodeDeliveryDetailsList[0].Destination)}, nil
+}
+
+func (a *CognitoAdapter) VerifyEmail(ctx context.Context, accessToken string, code string) error {
+	params := &cip.VerifyUserAttributeInput{
+		AccessToken:   aws.String(accessToken),
+		AttributeName: aws.String("email"),
+		Code:          aws.String(code),
+	}
+
+	_, err := a.client.VerifyUserAttribute(ctx, params)
+	if err != nil {
+		return handleEmailChangeError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) ResendEmailVerification(ctx context.Context, accessToken string) (*entity.Email, error) {
+	params := &cip.GetUserAttributeVerificationCodeInput{
+		AccessToken:   aws.String(accessToken),
+		AttributeName: aws.String("email"),
+	}
+
+	result, err := a.client.GetUserAttributeVerificationCode(ctx, params)
+	if err != nil {
+		return nil, handleEmailChangeError(err)
+	}
+	if result.CodeDeliveryDetails == nil {
+		return nil, errUnexpectedAuthResponse
+	}
+
+	return &entity.Email{Email: aws.ToString(result.CodeDeliveryDetails.Destination)}, nil
+}
+
+func (a *CognitoAdapter) AdminDisableUser(ctx context.Context, username string) error {
+	params := &cip.AdminDisableUserInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+	}
+
+	_, err := a.client.AdminDisableUser(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) AdminEnableUser(ctx context.Context, username string) error {
+	params := &cip.AdminEnableUserInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+	}
+
+	_, err := a.client.AdminEnableUser(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) AdminDeleteUser(ctx context.Context, username string) error {
+	params := &cip.AdminDeleteUserInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+	}
+
+	_, err := a.client.AdminDeleteUser(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) ListUsers(ctx context.Context, filter entity.UserFilter, limit int32, paginationToken string) (*entity.UserList, error) {
+	params := &cip.ListUsersInput{
+		UserPoolId: aws.String(a.poolID),
+	}
+	if filter.Attribute != "" {
+		operator := "="
+		if filter.Prefix {
+			operator = "^="
+		}
+		params.Filter = aws.String(fmt.Sprintf("%s %s %q", filter.Attribute, operator, filter.Value))
+	}
+	if limit > 0 {
+		params.Limit = aws.Int32(limit)
+	}
+	if paginationToken != "" {
+		params.PaginationToken = aws.String(paginationToken)
+	}
+
+	result, err := a.client.ListUsers(ctx, params)
+	if err != nil {
+		return nil, handleCognitoError(err)
+	}
+
+	list := &entity.UserList{
+		Users:           make([]entity.AdminUser, 0, len(result.Users)),
+		PaginationToken: aws.ToString(result.PaginationToken),
+	}
+	for _, user := range result.Users {
+		list.Users = append(list.Users, entity.AdminUser{
+			Username:   aws.ToString(user.Username),
+			Enabled:    user.Enabled,
+			Status:     string(user.UserStatus),
+			CreatedAt:  aws.ToTime(user.UserCreateDate),
+			UpdatedAt:  aws.ToTime(user.UserLastModifiedDate),
+			Attributes: toAttributeMap(user.Attributes),
+		})
+	}
+
+	return list, nil
+}
+
+func (a *CognitoAdapter) AdminGetUser(ctx context.Context, username string) (*entity.AdminUser, error) {
+	result, err := a.client.AdminGetUser(ctx, &cip.AdminGetUserInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+	})
+	if err != nil {
+		return nil, handleCognitoError(err)
+	}
+
+	return &entity.AdminUser{
+		Username:   aws.ToString(result.Username),
+		Enabled:    result.Enabled,
+		Status:     string(result.UserStatus),
+		CreatedAt:  aws.ToTime(result.UserCreateDate),
+		UpdatedAt:  aws.ToTime(result.UserLastModifiedDate),
+		Attributes: toAttributeMap(result.UserAttributes),
+	}, nil
+}
+
+// AdminResetUserPassword invalidates the current password; the user has to
+// finish the reset with the code Cognito sends, through /confirm-forgot.
+func (a *CognitoAdapter) AdminResetUserPassword(ctx context.Context, username string) error {
+	params := &cip.AdminResetUserPasswordInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+	}
+
+	_, err := a.client.AdminResetUserPassword(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+// AdminResendInvitation sends a new temporary password to a user created by
+// an administrator who has not logged in yet.
+func (a *CognitoAdapter) AdminResendInvitation(ctx context.Context, username string) error {
+	params := &cip.AdminCreateUserInput{
+		UserPoolId:    aws.String(a.poolID),
+		Username:      aws.String(username),
+		MessageAction: types.MessageActionTypeResend,
+	}
+
+	_, err := a.client.AdminCreateUser(ctx, params)
+	if err != nil {
+		return handleCognitoError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) CreateGroup(ctx context.Context, createGroup entity.CreateGroup) (*entity.Group, error) {
+	params := &cip.CreateGroupInput{
+		UserPoolId: aws.String(a.poolID),
+		GroupName:  aws.String(createGroup.Name),
+		Precedence: createGroup.Precedence,
+	}
+	if createGroup.Description != "" {
+		params.Description = aws.String(createGroup.Description)
+	}
+
+	result, err := a.client.CreateGroup(ctx, params)
+	if err != nil {
+		return nil, handleGroupError(err)
+	}
+
+	group := toGroup(*result.Group)
+	return &group, nil
+}
+
+func (a *CognitoAdapter) ListGroups(ctx context.Context, limit int32, nextToken string) (*entity.GroupList, error) {
+	params := &cip.ListGroupsInput{
+		UserPoolId: aws.String(a.poolID),
+	}
+	if limit > 0 {
+		params.Limit = aws.Int32(limit)
+	}
+	if nextToken != "" {
+		params.NextToken = aws.String(nextToken)
+	}
+
+	result, err := a.client.ListGroups(ctx, params)
+	if err != nil {
+		return nil, handleGroupError(err)
+	}
+
+	return toGroupList(result.Groups, result.NextToken), nil
+}
+
+func (a *CognitoAdapter) AdminListGroupsForUser(ctx context.Context, username string, limit int32, nextToken string) (*entity.GroupList, error) {
+	params := &cip.AdminListGroupsForUserInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+	}
+	if limit > 0 {
+		params.Limit = aws.Int32(limit)
+	}
+	if nextToken != "" {
+		params.NextToken = aws.String(nextToken)
+	}
+
+	result, err := a.client.AdminListGroupsForUser(ctx, params)
+	if err != nil {
+		return nil, handleCognitoError(err)
+	}
+
+	return toGroupList(result.Groups, result.NextToken), nil
+}
+
+func (a *CognitoAdapter) AdminAddUserToGroup(ctx context.Context, username string, group string) error {
+	params := &cip.AdminAddUserToGroupInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+		GroupName:  aws.String(group),
+	}
+
+	_, err := a.client.AdminAddUserToGroup(ctx, params)
+	if err != nil {
+		return handleGroupError(err)
+	}
+
+	return nil
+}
+
+func (a *CognitoAdapter) AdminRemoveUserFromGroup(ctx context.Context, username string, group string) error {
+	params := &cip.AdminRemoveUserFromGroupInput{
+		UserPoolId: aws.String(a.poolID),
+		Username:   aws.String(username),
+		GroupName:  aws.String(group),
+	}
+
+	_, err := a.client.AdminRemoveUserFromGroup(ctx, params)
+	if err != nil {
+		return handleGroupError(err)
+	}
+
+	return nil
+}
+
+func toGroup(group types.GroupType) entity.Group {
+	return entity.Group{
+		Name:        aws.ToString(group.GroupName),
+		Description: aws.ToString(group.Description),
+		Precedence:  group.Precedence,
+		CreatedAt:   aws.ToTime(group.CreationDate),
+		UpdatedAt:   aws.ToTime(group.LastModifiedDate),
+	}
+}
+
+func toGroupList(groups []types.GroupType, nextToken *string) *entity.GroupList {
+	list := &entity.GroupList{
+		Groups:    make([]entity.Group, 0, len(groups)),
+		NextToken: aws.ToString(nextToken),
+	}
+	for _, group := range groups {
+		list.Groups = append(list.Groups, toGroup(group))
+	}
+	return list
+}
+
+func toAttributeMap(attributes []types.AttributeType) map[string]string {
+	result := make(map[string]string, len(attributes))
+	for _, attribute := range attributes {
+		result[aws.ToString(attribute.Name)] = aws.ToString(attribute.Value)
+	}
+	return result
+}
+
+// toAuthResult turns an InitiateAuth or RespondToAuthChallenge response into
+// tokens or the next challenge. Cognito always sets exactly one of the two.
+func toAuthResult(authResult *types.AuthenticationResultType, challengeName types.ChallengeNameType, session *string, challengeParameters map[string]string) (*entity.AuthResult, error) {
+	if authResult != nil {
+		return &entity.AuthResult{Tokens: toLoginResult(authResult)}, nil
+	}
+	if challengeName == "" {
+		return nil, errUnexpectedAuthResponse
+	}
+
+	return &entity.AuthResult{
+		Challenge: &entity.AuthChallenge{
+			ChallengeName:       string(challengeName),
+			Session:             aws.ToString(session),
+			UserID:              challengeParameters["USER_ID_FOR_SRP"],
+			ChallengeParameters: challengeParameters,
+		},
+	}, nil
+}
+
+func toLoginResult(result *types.AuthenticationResultType) *entity.LoginResult {
+	return &entity.LoginResult{
+		AccessToken:  result.AccessToken,
+		ExpiresIn:    &result.ExpiresIn,
+		IdToken:      result.IdToken,
+		RefreshToken: result.RefreshToken,
+		TokenType:    result.TokenType,
+	}
+}
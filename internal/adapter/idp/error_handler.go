@@ -1,63 +1,255 @@
 package idp
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strings"
 
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 
 	"github.com/Zeta-Manu/manu-auth/pkg/utils"
 )
 
+var (
+	errUnsupportedChallenge = &utils.CustomError{
+		Message: "Unsupported challenge",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeUnsupportedChallenge,
+	}
+	errUnexpectedAuthResponse = &utils.CustomError{
+		Message: "Unexpected authentication response",
+		Status:  http.StatusBadGateway,
+		Code:    utils.ErrCodeUnexpectedResponse,
+	}
+	errSoftwareTokenMismatch = &utils.CustomError{
+		Message: "Invalid software token code",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeMFACodeMismatch,
+	}
+	errEmailInUse = &utils.CustomError{
+		Message: "Email already in use",
+		Status:  http.StatusConflict,
+		Code:    utils.ErrCodeEmailInUse,
+	}
+	errChallengeUserMismatch = &utils.CustomError{
+		Message: "user_id does not belong to email",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeInvalidParameter,
+	}
+	errEmailChangeDisabled = &utils.CustomError{
+		Message: "Changing email is not enabled",
+		Status:  http.StatusNotImplemented,
+		Code:    utils.ErrCodeEmailChangeDisabled,
+	}
+	errGroupExists = &utils.CustomError{
+		Message: "Group already exists",
+		Status:  http.StatusConflict,
+		Code:    utils.ErrCodeGroupExists,
+	}
+	errGroupNotFound = &utils.CustomError{
+		Message: "Group not found",
+		Status:  http.StatusNotFound,
+		Code:    utils.ErrCodeGroupNotFound,
+	}
+	errRefreshSubjectRequired = &utils.CustomError{
+		Message: "access_token is required to refresh with this app client",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeRefreshSubjectRequired,
+	}
+)
+
+// cognitoErrors maps every exception the user pool API can return. Order
+// doesn't matter, each error matches at most one type.
+var cognitoErrors = []struct {
+	match  func(error) bool
+	status int
+	code   string
+	msg    string
+}{
+	{is[*types.AliasExistsException], http.StatusConflict, utils.ErrCodeAliasExists, "Alias exists"},
+	{is[*types.CodeDeliveryFailureException], http.StatusBadGateway, utils.ErrCodeCodeDeliveryFailed, "Failed to deliver code"},
+	{is[*types.CodeMismatchException], http.StatusBadRequest, utils.ErrCodeCodeMismatch, "Invalid verification code"},
+	{is[*types.ConcurrentModificationException], http.StatusConflict, utils.ErrCodeConflict, "Concurrent modification, try again"},
+	{is[*types.DuplicateProviderException], http.StatusConflict, utils.ErrCodeConflict, "Identity provider already exists"},
+	{is[*types.EnableSoftwareTokenMFAException], http.StatusBadRequest, utils.ErrCodeMFACodeMismatch, "Invalid software token code"},
+	{is[*types.ExpiredCodeException], http.StatusBadRequest, utils.ErrCodeCodeExpired, "Verification code expired"},
+	{is[*types.ForbiddenException], http.StatusForbidden, utils.ErrCodeForbidden, "Forbidden"},
+	{is[*types.GroupExistsException], http.StatusConflict, utils.ErrCodeGroupExists, "Group already exists"},
+	{is[*types.InternalErrorException], http.StatusBadGateway, utils.ErrCodeIdPUnavailable, "Identity provider error"},
+	{is[*types.InvalidEmailRoleAccessPolicyException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.InvalidLambdaResponseException], http.StatusBadGateway, utils.ErrCodeLambdaFailed, "User pool trigger failed"},
+	{is[*types.InvalidOAuthFlowException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.InvalidParameterException], http.StatusBadRequest, utils.ErrCodeInvalidParameter, "Invalid parameter"},
+	{is[*types.InvalidSmsRoleAccessPolicyException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.InvalidSmsRoleTrustRelationshipException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.InvalidUserPoolConfigurationException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.LimitExceededException], http.StatusTooManyRequests, utils.ErrCodeLimitExceeded, "Attempt limit exceeded, try again later"},
+	{is[*types.MFAMethodNotFoundException], http.StatusBadRequest, utils.ErrCodeMFAMethodNotFound, "MFA method not found"},
+	{is[*types.PasswordResetRequiredException], http.StatusForbidden, utils.ErrCodePasswordResetRequired, "Password reset required"},
+	{is[*types.PreconditionNotMetException], http.StatusPreconditionFailed, utils.ErrCodePreconditionFailed, "Precondition not met"},
+	{is[*types.ResourceNotFoundException], http.StatusNotFound, utils.ErrCodeResourceNotFound, "Resource not found"},
+	{is[*types.ScopeDoesNotExistException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.SoftwareTokenMFANotFoundException], http.StatusBadRequest, utils.ErrCodeSoftwareTokenNotFound, "Software token MFA is not set up"},
+	{is[*types.TooManyFailedAttemptsException], http.StatusTooManyRequests, utils.ErrCodeTooManyFailedAttempts, "Too many failed attempts"},
+	{is[*types.TooManyRequestsException], http.StatusTooManyRequests, utils.ErrCodeTooManyRequests, "Too many requests"},
+	{is[*types.UnauthorizedException], http.StatusUnauthorized, utils.ErrCodeClientUnauthorized, "Client not authorized"},
+	{is[*types.UnexpectedLambdaException], http.StatusBadGateway, utils.ErrCodeLambdaFailed, "User pool trigger failed"},
+	{is[*types.UnsupportedIdentityProviderException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.UnsupportedOperationException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.UnsupportedTokenTypeException], http.StatusBadRequest, utils.ErrCodeUnsupportedTokenType, "Unsupported token type"},
+	{is[*types.UnsupportedUserStateException], http.StatusBadRequest, utils.ErrCodeUnsupportedUserState, "Unsupported user state"},
+	{is[*types.UserImportInProgressException], http.StatusConflict, utils.ErrCodeUserImportInProgress, "User import in progress"},
+	// The trigger's own message can carry internals, so it only reaches the logs.
+	{is[*types.UserLambdaValidationException], http.StatusBadRequest, utils.ErrCodeLambdaValidationFailed, "Request rejected by user pool trigger"},
+	{is[*types.UsernameExistsException], http.StatusConflict, utils.ErrCodeUsernameExists, "Username already exists"},
+	{is[*types.UserNotConfirmedException], http.StatusForbidden, utils.ErrCodeUserNotConfirmed, "User not confirm"},
+	{is[*types.UserNotFoundException], http.StatusNotFound, utils.ErrCodeUserNotFound, "User not found"},
+	{is[*types.UserPoolAddOnNotEnabledException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+	{is[*types.UserPoolTaggingException], http.StatusInternalServerError, utils.ErrCodeIdPMisconfigured, "Identity provider misconfigured"},
+}
+
+func is[T error](err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
+
 func handleCognitoError(err error) error {
+	return withCause(cognitoError(err), err)
+}
+
+func cognitoError(err error) *utils.CustomError {
 	var invalidPasswordErr *types.InvalidPasswordException
-	var invalidParameterErr *types.InvalidParameterException
-	var usernameExistsErr *types.UsernameExistsException
 	var notAuthorizedErr *types.NotAuthorizedException
-	var userNotFoundErr *types.UserNotFoundException
-	var userNotConfirmErr *types.UserNotConfirmedException
-	var aliasExistErr *types.AliasExistsException
 
 	switch {
 	case errors.As(err, &invalidPasswordErr):
-		return &utils.CustomError{
-			Message: "Invalid password",
-			Status:  http.StatusBadRequest,
-		}
-	case errors.As(err, &invalidParameterErr):
-		return &utils.CustomError{
-			Message: "Invalid parameter",
-			Status:  http.StatusBadRequest,
-		}
-	case errors.As(err, &usernameExistsErr):
-		return &utils.CustomError{
-			Message: "Username already exists",
-			Status:  http.StatusConflict,
-		}
+		return invalidPasswordError(invalidPasswordErr.ErrorMessage())
 	case errors.As(err, &notAuthorizedErr):
+		return notAuthorizedError(notAuthorizedErr.ErrorMessage())
+	case errors.Is(err, context.DeadlineExceeded):
 		return &utils.CustomError{
-			Message: "Not Authorized",
-			Status:  http.StatusUnauthorized,
-		}
-	case errors.As(err, &userNotFoundErr):
-		return &utils.CustomError{
-			Message: "User not found",
-			Status:  http.StatusNotFound,
-		}
-	case errors.As(err, &userNotConfirmErr):
-		return &utils.CustomError{
-			Message: "User not confirm",
-			Status:  http.StatusForbidden,
+			Message: "Identity provider timed out",
+			Status:  http.StatusGatewayTimeout,
+			Code:    utils.ErrCodeIdPTimeout,
 		}
-	case errors.As(err, &aliasExistErr):
-		return &utils.CustomError{
-			Message: "Alias exists",
-			Status:  http.StatusConflict,
+	}
+
+	for _, mapping := range cognitoErrors {
+		if mapping.match(err) {
+			return &utils.CustomError{Message: mapping.msg, Status: mapping.status, Code: mapping.code}
 		}
+	}
+	return &utils.CustomError{
+		Message: "Internal error",
+		Status:  http.StatusInternalServerError,
+		Code:    utils.ErrCodeInternal,
+	}
+}
+
+// invalidPasswordError tells which rule of the password policy was broken.
+// Cognito only says so in the message, e.g. "Password did not conform with
+// policy: Password must have uppercase characters".
+func invalidPasswordError(message string) *utils.CustomError {
+	code := utils.ErrCodePasswordInvalid
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "not long enough"):
+		code = utils.ErrCodePasswordTooShort
+	case strings.Contains(lower, "lowercase"):
+		code = utils.ErrCodePasswordMissingLowercase
+	case strings.Contains(lower, "uppercase"):
+		code = utils.ErrCodePasswordMissingUppercase
+	case strings.Contains(lower, "numeric"):
+		code = utils.ErrCodePasswordMissingNumber
+	case strings.Contains(lower, "symbol"):
+		code = utils.ErrCodePasswordMissingSymbol
+	case strings.Contains(lower, "previously been used"):
+		code = utils.ErrCodePasswordReused
+	}
+	return &utils.CustomError{
+		Message: "Invalid password",
+		Status:  http.StatusBadRequest,
+		Code:    code,
+	}
+}
+
+// notAuthorizedError splits NotAuthorizedException, which Cognito uses for
+// wrong passwords as much as for disabled users and dead tokens. A disabled
+// user gets the same answer as a wrong password, see handleLoginError.
+func notAuthorizedError(message string) *utils.CustomError {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "attempts exceeded"):
+		return &utils.CustomError{Message: "Too many failed attempts", Status: http.StatusTooManyRequests, Code: utils.ErrCodeTooManyFailedAttempts}
+	case strings.Contains(lower, "session"):
+		return &utils.CustomError{Message: "Session expired", Status: http.StatusUnauthorized, Code: utils.ErrCodeSessionExpired}
+	case strings.Contains(lower, "access token"):
+		return &utils.CustomError{Message: "Invalid or revoked access token", Status: http.StatusUnauthorized, Code: utils.ErrCodeTokenInvalid}
 	default:
-		return &utils.CustomError{
-			Message: "Internal error",
-			Status:  http.StatusInternalServerError,
-		}
+		return &utils.CustomError{Message: "Not Authorized", Status: http.StatusUnauthorized, Code: utils.ErrCodeNotAuthorized}
+	}
+}
+
+// withCause returns a copy of mapped carrying err and its AWS request ID, so
+// the shared error values above are never modified.
+func withCause(mapped *utils.CustomError, err error) error {
+	result := *mapped
+	result.Err = err
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		result.RequestID = responseErr.ServiceRequestID()
+	}
+	return &result
+}
+
+// handleLoginError answers an unknown email like a wrong password or a
+// disabled user, so a login doesn't reveal whether the account exists or is
+// waiting for deletion.
+func handleLoginError(err error) error {
+	if is[*types.UserNotFoundException](err) {
+		return withCause(notAuthorizedError(""), err)
+	}
+	return handleCognitoError(err)
+}
+
+// Cognito answers an expired, revoked or foreign refresh token with NotAuthorizedException,
+// which would otherwise read as a wrong password.
+func handleRefreshTokenError(err error) error {
+	var notAuthorizedErr *types.NotAuthorizedException
+	if errors.As(err, &notAuthorizedErr) {
+		return withCause(&utils.CustomError{
+			Message: "Refresh token expired or revoked",
+			Status:  http.StatusUnauthorized,
+			Code:    utils.ErrCodeRefreshTokenInvalid,
+		}, err)
+	}
+	return handleCognitoError(err)
+}
+
+// handleEmailChangeError maps the errors of the email change flow. Cognito
+// reports an email that already belongs to another user as AliasExistsException.
+func handleEmailChangeError(err error) error {
+	var aliasExistErr *types.AliasExistsException
+	if errors.As(err, &aliasExistErr) {
+		return withCause(errEmailInUse, err)
+	}
+	return handleCognitoError(err)
+}
+
+// handleGroupError maps the errors specific to group management before
+// falling back to handleCognitoError.
+func handleGroupError(err error) error {
+	var groupExistsErr *types.GroupExistsException
+	var resourceNotFoundErr *types.ResourceNotFoundException
+
+	switch {
+	case errors.As(err, &groupExistsErr):
+		return withCause(errGroupExists, err)
+	case errors.As(err, &resourceNotFoundErr):
+		return withCause(errGroupNotFound, err)
+	default:
+		return handleCognitoError(err)
 	}
 }
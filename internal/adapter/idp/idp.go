@@ -0,0 +1,48 @@
+package idp
+
+import (
+	"context"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+)
+
+// IdentityProvider is the set of user operations the API is built on.
+// CognitoAdapter talks to AWS Cognito, MemoryAdapter keeps everything in process.
+type IdentityProvider interface {
+	Register(ctx context.Context, userRegistration entity.UserRegistration) (string, error)
+	Login(ctx context.Context, userLogin entity.UserLogin) (*entity.AuthResult, error)
+	RespondToChallenge(ctx context.Context, challengeResponse entity.ChallengeResponse) (*entity.AuthResult, error)
+	RefreshToken(ctx context.Context, refreshToken entity.RefreshToken) (*entity.LoginResult, error)
+	ConfirmRegistration(ctx context.Context, userRegistrationConfirm entity.UserRegistrationConfirm) error
+	ResendConfirmationCode(ctx context.Context, email string) (*entity.Email, error)
+	ForgotPassword(ctx context.Context, email string) (*entity.Email, error)
+	ConfirmForgotPassword(ctx context.Context, userResetPassword entity.UserResetPassword) error
+	ChangePassword(ctx context.Context, accessToken string, changePassword entity.UserChangePassword) error
+	GlobalSignOut(ctx context.Context, accessToken string) error
+	RevokeToken(ctx context.Context, refreshToken string) error
+	AssociateSoftwareToken(ctx context.Context, accessToken string) (*entity.SoftwareTokenAssociation, error)
+	VerifySoftwareToken(ctx context.Context, accessToken string, verifySoftwareToken entity.VerifySoftwareToken) error
+	SetMFAPreference(ctx context.Context, accessToken string, mfaPreference entity.MFAPreference) error
+	GetUser(ctx context.Context, accessToken string) (*entity.User, error)
+	UpdateUserAttributes(ctx context.Context, accessToken string, attributes map[string]string) error
+	ChangeEmail(ctx context.Context, accessToken string, email string) (*entity.Email, error)
+	VerifyEmail(ctx context.Context, accessToken string, code string) error
+	ResendEmailVerification(ctx context.Context, accessToken string) (*entity.Email, error)
+	AdminDisableUser(ctx context.Context, username string) error
+	AdminEnableUser(ctx context.Context, username string) error
+	AdminDeleteUser(ctx context.Context, username string) error
+	ListUsers(ctx context.Context, filter entity.UserFilter, limit int32, paginationToken string) (*entity.UserList, error)
+	AdminGetUser(ctx context.Context, username string) (*entity.AdminUser, error)
+	AdminResetUserPassword(ctx context.Context, username string) error
+	AdminResendInvitation(ctx context.Context, username string) error
+	CreateGroup(ctx context.Context, createGroup entity.CreateGroup) (*entity.Group, error)
+	ListGroups(ctx context.Context, limit int32, nextToken string) (*entity.GroupList, error)
+	AdminListGroupsForUser(ctx context.Context, username string, limit int32, nextToken string) (*entity.GroupList, error)
+	AdminAddUserToGroup(ctx context.Context, username string, group string) error
+	AdminRemoveUserFromGroup(ctx context.Context, username string, group string) error
+}
+
+var (
+	_ IdentityProvider = (*CognitoAdapter)(nil)
+	_ IdentityProvider = (*MemoryAdapter)(nil)
+)
@@ -0,0 +1,60 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Zeta-Manu/manu-auth/pkg/metrics"
+	"github.com/Zeta-Manu/manu-auth/pkg/tracing"
+)
+
+// instrumentCognito wraps every Cognito call, retries included, in a client
+// span and measures it. Failures are counted and tagged with the code
+// handleCognitoError maps them to.
+func instrumentCognito(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CognitoTelemetry", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		operation := awsmiddleware.GetOperationName(ctx)
+		ctx, span := tracing.Tracer().Start(ctx, "Cognito."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.RPCSystemKey.String("aws-api"),
+				semconv.RPCService("CognitoIdentityProvider"),
+				semconv.RPCMethod(operation),
+			))
+		defer span.End()
+
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		metrics.CognitoRequestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+		if requestID := awsRequestID(metadata, err); requestID != "" {
+			span.SetAttributes(semconv.AWSRequestID(requestID))
+		}
+		if err != nil {
+			code := cognitoError(err).Code
+			metrics.CognitoErrors.WithLabelValues(operation, code).Inc()
+			span.SetAttributes(attribute.String("auth.error_code", code))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, code)
+		}
+		return out, metadata, err
+	}), middleware.After)
+}
+
+func awsRequestID(metadata middleware.Metadata, err error) string {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.ServiceRequestID()
+	}
+	requestID, _ := awsmiddleware.GetRequestIDMetadata(metadata)
+	return requestID
+}
@@ -0,0 +1,1287 @@
+package idp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/totp"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+const (
+	memoryIssuer          = "manu-auth-memory"
+	memoryTokenTTL        = time.Hour
+	memoryRefreshTokenTTL = 30 * 24 * time.Hour
+	memoryConfirmCodeTTL  = 24 * time.Hour
+	memoryResetCodeTTL    = time.Hour
+	memoryDefaultClientID = "memory-client"
+	memorySessionTTL      = 3 * time.Minute
+	memoryEmailCodeTTL    = 24 * time.Hour
+	memoryMaxListLimit    = 60
+)
+
+type memoryCode struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryRefreshToken struct {
+	email     string
+	expiresAt time.Time
+}
+
+type memoryChallenge struct {
+	email     string
+	name      string
+	expiresAt time.Time
+}
+
+type memoryGroup struct {
+	description string
+	precedence  *int32
+	createdAt   time.Time
+	updatedAt   time.Time
+}
+
+type memoryUser struct {
+	sub          string
+	name         string
+	email        string
+	passwordHash []byte
+	confirmed    bool
+	confirmCode  *memoryCode
+	resetCode    *memoryCode
+	signedOutAt  time.Time
+	// mustChangePassword makes the next login answer NEW_PASSWORD_REQUIRED.
+	mustChangePassword bool
+	totpSecret         string
+	totpVerified       bool
+	totpEnabled        bool
+	// attributes holds everything besides sub, name and email, e.g. locale or custom:*.
+	attributes map[string]string
+	// pendingEmail waits for emailCode; until then the user keeps logging in with email.
+	pendingEmail string
+	emailCode    *memoryCode
+	disabled     bool
+	// resetRequired blocks logins until the password is reset, after AdminResetUserPassword.
+	resetRequired bool
+	groups        []string
+	createdAt     time.Time
+	updatedAt     time.Time
+}
+
+// MemoryAdapter is a self-contained identity provider for local development and CI.
+// Users, codes and signing keys live only as long as the process; confirmation and
+// reset codes are written to the log instead of being delivered.
+type MemoryAdapter struct {
+	mu            sync.Mutex
+	users         map[string]*memoryUser
+	subs          map[string]string
+	refreshTokens map[string]memoryRefreshToken
+	challenges    map[string]memoryChallenge
+	policy        PasswordPolicy
+	groups        map[string]*memoryGroup
+	// bootstrapGroups puts users into groups as they sign up, keyed by email.
+	bootstrapGroups map[string][]string
+	clientID        string
+	key             *rsa.PrivateKey
+	keyID           string
+	keySet          jwk.Set
+	logger          *zap.Logger
+}
+
+// NewMemoryAdapter creates an empty provider. bootstrapGroups lists, by email,
+// the groups users join when they sign up, which is how the first admin is made.
+func NewMemoryAdapter(clientID string, policy PasswordPolicy, bootstrapGroups map[string][]string, logger *zap.Logger) (*MemoryAdapter, error) {
+	if clientID == "" {
+		clientID = memoryDefaultClientID
+	}
+	if policy.MinimumLength == 0 {
+		policy = DefaultPasswordPolicy()
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := jwk.FromRaw(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	_ = publicKey.Set(jwk.KeyIDKey, keyID)
+	_ = publicKey.Set(jwk.AlgorithmKey, jwa.RS256)
+	_ = publicKey.Set(jwk.KeyUsageKey, jwk.ForSignature)
+
+	keySet := jwk.NewSet()
+	if err := keySet.AddKey(publicKey); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	groups := make(map[string]*memoryGroup)
+	memberships := make(map[string][]string, len(bootstrapGroups))
+	for email, names := range bootstrapGroups {
+		email = normalizeEmail(email)
+		memberships[email] = append(memberships[email], names...)
+		for _, name := range names {
+			groups[name] = &memoryGroup{createdAt: now, updatedAt: now}
+		}
+	}
+
+	return &MemoryAdapter{
+		users:           make(map[string]*memoryUser),
+		subs:            make(map[string]string),
+		refreshTokens:   make(map[string]memoryRefreshToken),
+		challenges:      make(map[string]memoryChallenge),
+		policy:          policy,
+		clientID:        clientID,
+		groups:          groups,
+		bootstrapGroups: memberships,
+		key:             key,
+		keyID:           keyID,
+		keySet:          keySet,
+		logger:          logger,
+	}, nil
+}
+
+// KeySet returns the public keys tokens issued by this adapter are signed with.
+func (a *MemoryAdapter) KeySet() jwk.Set {
+	return a.keySet
+}
+
+func (a *MemoryAdapter) Issuer() string {
+	return memoryIssuer
+}
+
+func (a *MemoryAdapter) ClientID() string {
+	return a.clientID
+}
+
+func (a *MemoryAdapter) Register(ctx context.Context, userRegistration entity.UserRegistration) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	email := normalizeEmail(userRegistration.Email)
+	if email == "" {
+		return "", errMemoryInvalidParameter
+	}
+	if _, exists := a.users[email]; exists {
+		return "", errMemoryUsernameExists
+	}
+	if violation := a.policy.Violation(userRegistration.Password); violation != "" {
+		return "", invalidPasswordError(violation)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(userRegistration.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	sub, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	user := &memoryUser{
+		sub:          sub,
+		name:         userRegistration.Name,
+		email:        email,
+		passwordHash: hash,
+		groups:       append([]string(nil), a.bootstrapGroups[email]...),
+		createdAt:    now,
+		updatedAt:    now,
+	}
+	if user.confirmCode, err = a.issueCode(email, "confirmation", memoryConfirmCodeTTL); err != nil {
+		return "", err
+	}
+
+	a.users[email] = user
+	a.subs[sub] = email
+
+	return maskEmail(email), nil
+}
+
+func (a *MemoryAdapter) Login(ctx context.Context, userLogin entity.UserLogin) (*entity.AuthResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// An unknown email or a disabled account is answered like a wrong
+	// password, so a login doesn't reveal whether the account exists.
+	user, ok := a.users[normalizeEmail(userLogin.Email)]
+	if !ok {
+		return nil, errMemoryNotAuthorized
+	}
+	if bcrypt.CompareHashAndPassword(user.passwordHash, []byte(userLogin.Password)) != nil {
+		return nil, errMemoryNotAuthorized
+	}
+	if user.disabled {
+		return nil, errMemoryNotAuthorized
+	}
+	if user.resetRequired {
+		return nil, errMemoryPasswordResetRequired
+	}
+	if !user.confirmed {
+		return nil, errMemoryUserNotConfirmed
+	}
+
+	return a.nextAuthStep(user)
+}
+
+func (a *MemoryAdapter) RespondToChallenge(ctx context.Context, challengeResponse entity.ChallengeResponse) (*entity.AuthResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	challenge, ok := a.challenges[challengeResponse.Session]
+	delete(a.challenges, challengeResponse.Session)
+	if !ok || time.Now().After(challenge.expiresAt) ||
+		challenge.email != normalizeEmail(challengeResponse.Email) ||
+		challenge.name != challengeResponse.ChallengeName {
+		return nil, errMemoryInvalidSession
+	}
+
+	user, ok := a.users[challenge.email]
+	if !ok {
+		return nil, errMemoryUserNotFound
+	}
+	if challengeResponse.UserID != "" && challengeResponse.UserID != user.sub {
+		return nil, errMemoryInvalidSession
+	}
+
+	switch challenge.name {
+	case entity.ChallengeNewPasswordRequired:
+		if violation := a.policy.Violation(challengeResponse.NewPassword); violation != "" {
+			return nil, invalidPasswordError(violation)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(challengeResponse.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		user.passwordHash = hash
+		user.mustChangePassword = false
+		if name, ok := challengeResponse.Attributes["name"]; ok {
+			user.name = name
+		}
+	case entity.ChallengeSoftwareTokenMFA:
+		if !totp.Validate(user.totpSecret, challengeResponse.Code, time.Now()) {
+			return nil, errMemoryCodeMismatch
+		}
+		tokens, err := a.issueTokens(user, true)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.AuthResult{Tokens: tokens}, nil
+	default:
+		return nil, errMemoryUnsupportedChallenge
+	}
+
+	return a.nextAuthStep(user)
+}
+
+func (a *MemoryAdapter) RefreshToken(ctx context.Context, refreshToken entity.RefreshToken) (*entity.LoginResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stored, ok := a.refreshTokens[refreshToken.RefreshToken]
+	if !ok || time.Now().After(stored.expiresAt) {
+		delete(a.refreshTokens, refreshToken.RefreshToken)
+		return nil, errMemoryRefreshTokenInvalid
+	}
+
+	user, ok := a.users[stored.email]
+	if !ok || user.disabled {
+		return nil, errMemoryRefreshTokenInvalid
+	}
+
+	// Like Cognito without rotation, refreshing does not issue a new refresh token.
+	return a.issueTokens(user, false)
+}
+
+func (a *MemoryAdapter) ConfirmRegistration(ctx context.Context, userRegistrationConfirm entity.UserRegistrationConfirm) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.users[normalizeEmail(userRegistrationConfirm.Email)]
+	if !ok {
+		return errMemoryUserNotFound
+	}
+	if user.confirmed {
+		return errMemoryNotAuthorized
+	}
+	if err := checkCode(user.confirmCode, userRegistrationConfirm.ConfirmationCode); err != nil {
+		return err
+	}
+
+	user.confirmed = true
+	user.confirmCode = nil
+	return nil
+}
+
+func (a *MemoryAdapter) ResendConfirmationCode(ctx context.Context, email string) (*entity.Email, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.users[normalizeEmail(email)]
+	if !ok {
+		return nil, errMemoryUserNotFound
+	}
+	if user.confirmed {
+		return nil, errMemoryInvalidParameter
+	}
+
+	code, err := a.issueCode(user.email, "confirmation", memoryConfirmCodeTTL)
+	if err != nil {
+		return nil, err
+	}
+	user.confirmCode = code
+
+	return &entity.Email{Email: maskEmail(user.email)}, nil
+}
+
+func (a *MemoryAdapter) ForgotPassword(ctx context.Context, email string) (*entity.Email, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.users[normalizeEmail(email)]
+	if !ok {
+		return nil, errMemoryUserNotFound
+	}
+
+	code, err := a.issueCode(user.email, "password reset", memoryResetCodeTTL)
+	if err != nil {
+		return nil, err
+	}
+	user.resetCode = code
+
+	return &entity.Email{Email: maskEmail(user.email)}, nil
+}
+
+func (a *MemoryAdapter) ConfirmForgotPassword(ctx context.Context, userResetPassword entity.UserResetPassword) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.users[normalizeEmail(userResetPassword.Email)]
+	if !ok {
+		return errMemoryUserNotFound
+	}
+	if err := checkCode(user.resetCode, userResetPassword.ConfirmationCode); err != nil {
+		return err
+	}
+	if violation := a.policy.Violation(userResetPassword.NewPassword); violation != "" {
+		return invalidPasswordError(violation)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(userResetPassword.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user.passwordHash = hash
+	user.resetCode = nil
+	user.resetRequired = false
+	user.updatedAt = time.Now()
+	// Cognito confirms the account as a side effect of a successful reset.
+	user.confirmed = true
+	return nil
+}
+
+func (a *MemoryAdapter) ChangePassword(ctx context.Context, accessToken string, changePassword entity.UserChangePassword) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword(user.passwordHash, []byte(changePassword.PreviousPassword)) != nil {
+		return errMemoryNotAuthorized
+	}
+	if violation := a.policy.Violation(changePassword.ProposedPassword); violation != "" {
+		return invalidPasswordError(violation)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(changePassword.ProposedPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user.passwordHash = hash
+	return nil
+}
+
+func (a *MemoryAdapter) GlobalSignOut(ctx context.Context, accessToken string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+
+	// Tokens only carry whole seconds in iat, like revocation.List.
+	user.signedOutAt = time.Now().Truncate(time.Second)
+	for token, stored := range a.refreshTokens {
+		if stored.email == user.email {
+			delete(a.refreshTokens, token)
+		}
+	}
+	return nil
+}
+
+func (a *MemoryAdapter) RevokeToken(ctx context.Context, refreshToken string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Revoking an unknown token succeeds, as it does with Cognito.
+	delete(a.refreshTokens, refreshToken)
+	return nil
+}
+
+func (a *MemoryAdapter) AdminDisableUser(ctx context.Context, username string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return errMemoryUserNotFound
+	}
+	user.disabled = true
+	user.updatedAt = time.Now()
+	return nil
+}
+
+func (a *MemoryAdapter) AdminEnableUser(ctx context.Context, username string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return errMemoryUserNotFound
+	}
+	user.disabled = false
+	user.updatedAt = time.Now()
+	return nil
+}
+
+func (a *MemoryAdapter) AdminDeleteUser(ctx context.Context, username string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return errMemoryUserNotFound
+	}
+
+	delete(a.users, user.email)
+	delete(a.subs, user.sub)
+	for token, stored := range a.refreshTokens {
+		if stored.email == user.email {
+			delete(a.refreshTokens, token)
+		}
+	}
+	return nil
+}
+
+func (a *MemoryAdapter) ListUsers(ctx context.Context, filter entity.UserFilter, limit int32, paginationToken string) (*entity.UserList, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if limit <= 0 || limit > memoryMaxListLimit {
+		limit = memoryMaxListLimit
+	}
+	// The pagination token is the email to continue from.
+	start := ""
+	if paginationToken != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(paginationToken)
+		if err != nil {
+			return nil, errMemoryInvalidParameter
+		}
+		start = string(decoded)
+	}
+
+	emails := make([]string, 0, len(a.users))
+	for email := range a.users {
+		if email >= start {
+			emails = append(emails, email)
+		}
+	}
+	sort.Strings(emails)
+
+	list := &entity.UserList{Users: []entity.AdminUser{}}
+	for _, email := range emails {
+		user := a.users[email]
+		if !user.matches(filter) {
+			continue
+		}
+		if len(list.Users) == int(limit) {
+			list.PaginationToken = base64.RawURLEncoding.EncodeToString([]byte(email))
+			break
+		}
+		list.Users = append(list.Users, user.adminUser())
+	}
+
+	return list, nil
+}
+
+func (a *MemoryAdapter) AdminGetUser(ctx context.Context, username string) (*entity.AdminUser, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return nil, errMemoryUserNotFound
+	}
+	adminUser := user.adminUser()
+	return &adminUser, nil
+}
+
+func (a *MemoryAdapter) AdminResetUserPassword(ctx context.Context, username string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return errMemoryUserNotFound
+	}
+
+	code, err := a.issueCode(user.email, "reset", memoryResetCodeTTL)
+	if err != nil {
+		return err
+	}
+	user.resetCode = code
+	user.resetRequired = true
+	user.updatedAt = time.Now()
+	return nil
+}
+
+func (a *MemoryAdapter) AdminResendInvitation(ctx context.Context, username string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return errMemoryUserNotFound
+	}
+	// As in Cognito, only users who never replaced their temporary password can be invited again.
+	if !user.mustChangePassword {
+		return errMemoryUnsupportedUserState
+	}
+
+	password, hash, err := temporaryPassword()
+	if err != nil {
+		return err
+	}
+
+	user.passwordHash = hash
+	user.updatedAt = time.Now()
+	a.logger.Info("Issued temporary password", zap.String("Email", user.email), zap.String("Password", password))
+	return nil
+}
+
+// AdminCreateUser invites a user the way Cognito's AdminCreateUser does: the
+// account is confirmed, a temporary password is written to the log, and the
+// first login answers NEW_PASSWORD_REQUIRED until it is replaced.
+func (a *MemoryAdapter) AdminCreateUser(ctx context.Context, email string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	email = normalizeEmail(email)
+	if email == "" {
+		return errMemoryInvalidParameter
+	}
+	if _, exists := a.users[email]; exists {
+		return errMemoryUsernameExists
+	}
+
+	password, hash, err := temporaryPassword()
+	if err != nil {
+		return err
+	}
+	sub, err := newUUID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	a.users[email] = &memoryUser{
+		sub:                sub,
+		email:              email,
+		passwordHash:       hash,
+		confirmed:          true,
+		mustChangePassword: true,
+		groups:             append([]string(nil), a.bootstrapGroups[email]...),
+		createdAt:          now,
+		updatedAt:          now,
+	}
+	a.subs[sub] = email
+
+	a.logger.Info("Issued temporary password", zap.String("Email", email), zap.String("Password", password))
+	return nil
+}
+
+func temporaryPassword() (string, []byte, error) {
+	suffix, err := randomHex(6)
+	if err != nil {
+		return "", nil, err
+	}
+	// The fixed prefix satisfies every character class the policy may require.
+	password := "Tmp1!" + suffix
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, err
+	}
+	return password, hash, nil
+}
+
+func (a *MemoryAdapter) CreateGroup(ctx context.Context, createGroup entity.CreateGroup) (*entity.Group, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if createGroup.Name == "" {
+		return nil, errMemoryInvalidParameter
+	}
+	if _, exists := a.groups[createGroup.Name]; exists {
+		return nil, errMemoryGroupExists
+	}
+
+	now := time.Now()
+	group := &memoryGroup{
+		description: createGroup.Description,
+		precedence:  createGroup.Precedence,
+		createdAt:   now,
+		updatedAt:   now,
+	}
+	a.groups[createGroup.Name] = group
+
+	result := group.toGroup(createGroup.Name)
+	return &result, nil
+}
+
+func (a *MemoryAdapter) ListGroups(ctx context.Context, limit int32, nextToken string) (*entity.GroupList, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	names := make([]string, 0, len(a.groups))
+	for name := range a.groups {
+		names = append(names, name)
+	}
+	return a.groupPage(names, limit, nextToken)
+}
+
+func (a *MemoryAdapter) AdminListGroupsForUser(ctx context.Context, username string, limit int32, nextToken string) (*entity.GroupList, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return nil, errMemoryUserNotFound
+	}
+	return a.groupPage(append([]string(nil), user.groups...), limit, nextToken)
+}
+
+func (a *MemoryAdapter) AdminAddUserToGroup(ctx context.Context, username string, group string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return errMemoryUserNotFound
+	}
+	if _, exists := a.groups[group]; !exists {
+		return errMemoryGroupNotFound
+	}
+
+	// Adding a member twice succeeds without a duplicate, as in Cognito.
+	for _, name := range user.groups {
+		if name == group {
+			return nil
+		}
+	}
+	user.groups = append(user.groups, group)
+	user.updatedAt = time.Now()
+	return nil
+}
+
+func (a *MemoryAdapter) AdminRemoveUserFromGroup(ctx context.Context, username string, group string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.userByUsername(username)
+	if !ok {
+		return errMemoryUserNotFound
+	}
+	if _, exists := a.groups[group]; !exists {
+		return errMemoryGroupNotFound
+	}
+
+	for i, name := range user.groups {
+		if name == group {
+			user.groups = append(user.groups[:i], user.groups[i+1:]...)
+			user.updatedAt = time.Now()
+			break
+		}
+	}
+	return nil
+}
+
+// groupPage returns one page of the named groups in name order; the token is
+// the name to continue from. It must be called with a.mu held.
+func (a *MemoryAdapter) groupPage(names []string, limit int32, nextToken string) (*entity.GroupList, error) {
+	if limit <= 0 || limit > memoryMaxListLimit {
+		limit = memoryMaxListLimit
+	}
+	start := ""
+	if nextToken != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(nextToken)
+		if err != nil {
+			return nil, errMemoryInvalidParameter
+		}
+		start = string(decoded)
+	}
+	sort.Strings(names)
+
+	list := &entity.GroupList{Groups: []entity.Group{}}
+	for _, name := range names {
+		group, ok := a.groups[name]
+		if !ok || name < start {
+			continue
+		}
+		if len(list.Groups) == int(limit) {
+			list.NextToken = base64.RawURLEncoding.EncodeToString([]byte(name))
+			break
+		}
+		list.Groups = append(list.Groups, group.toGroup(name))
+	}
+
+	return list, nil
+}
+
+// userByUsername accepts the username (the sub) or the email alias, as the
+// Cognito admin API does. It must be called with a.mu held.
+func (a *MemoryAdapter) userByUsername(username string) (*memoryUser, bool) {
+	if email, ok := a.subs[username]; ok {
+		username = email
+	}
+	user, ok := a.users[normalizeEmail(username)]
+	return user, ok
+}
+
+// nextAuthStep must be called with a.mu held, after the user proved their password.
+func (a *MemoryAdapter) nextAuthStep(user *memoryUser) (*entity.AuthResult, error) {
+	if user.mustChangePassword {
+		return a.startChallenge(user, entity.ChallengeNewPasswordRequired, map[string]string{
+			"USER_ID_FOR_SRP":    user.sub,
+			"requiredAttributes": "[]",
+		})
+	}
+	if user.totpEnabled {
+		return a.startChallenge(user, entity.ChallengeSoftwareTokenMFA, map[string]string{
+			"USER_ID_FOR_SRP": user.sub,
+		})
+	}
+
+	tokens, err := a.issueTokens(user, true)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.AuthResult{Tokens: tokens}, nil
+}
+
+func (a *MemoryAdapter) startChallenge(user *memoryUser, name string, parameters map[string]string) (*entity.AuthResult, error) {
+	session, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	a.challenges[session] = memoryChallenge{
+		email:     user.email,
+		name:      name,
+		expiresAt: time.Now().Add(memorySessionTTL),
+	}
+
+	return &entity.AuthResult{
+		Challenge: &entity.AuthChallenge{
+			ChallengeName:       name,
+			Session:             session,
+			UserID:              user.sub,
+			ChallengeParameters: parameters,
+		},
+	}, nil
+}
+
+func (a *MemoryAdapter) AssociateSoftwareToken(ctx context.Context, accessToken string) (*entity.SoftwareTokenAssociation, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// A new association replaces the previous authenticator, as in Cognito.
+	user.totpSecret = secret
+	user.totpVerified = false
+	user.totpEnabled = false
+
+	return &entity.SoftwareTokenAssociation{
+		SecretCode:  secret,
+		AccountName: user.email,
+	}, nil
+}
+
+func (a *MemoryAdapter) VerifySoftwareToken(ctx context.Context, accessToken string, verifySoftwareToken entity.VerifySoftwareToken) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+	if user.totpSecret == "" {
+		return errMemoryInvalidParameter
+	}
+	if !totp.Validate(user.totpSecret, verifySoftwareToken.Code, time.Now()) {
+		return errMemorySoftwareTokenMismatch
+	}
+
+	user.totpVerified = true
+	return nil
+}
+
+func (a *MemoryAdapter) SetMFAPreference(ctx context.Context, accessToken string, mfaPreference entity.MFAPreference) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+	// Users of the in-memory provider have no phone number to send codes to.
+	if mfaPreference.SMS != nil && mfaPreference.SMS.Enabled {
+		return errMemoryInvalidParameter
+	}
+	if mfaPreference.TOTP != nil {
+		if mfaPreference.TOTP.Enabled && !user.totpVerified {
+			return errMemoryInvalidParameter
+		}
+		user.totpEnabled = mfaPreference.TOTP.Enabled
+	}
+
+	return nil
+}
+
+func (a *MemoryAdapter) GetUser(ctx context.Context, accessToken string) (*entity.User, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.User{Username: user.sub, Attributes: user.allAttributes()}, nil
+}
+
+func (a *MemoryAdapter) UpdateUserAttributes(ctx context.Context, accessToken string, attributes map[string]string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+	// Validate everything first so a rejected update changes nothing, as in Cognito.
+	for name := range attributes {
+		switch name {
+		case "sub", "email", "email_verified", "phone_number_verified":
+			return errMemoryInvalidParameter
+		}
+	}
+
+	user.updatedAt = time.Now()
+	for name, value := range attributes {
+		if name == "name" {
+			user.name = value
+			continue
+		}
+		if user.attributes == nil {
+			user.attributes = make(map[string]string)
+		}
+		user.attributes[name] = value
+	}
+
+	return nil
+}
+
+func (a *MemoryAdapter) ChangeEmail(ctx context.Context, accessToken string, email string) (*entity.Email, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	email = normalizeEmail(email)
+	if email == "" || email == user.email {
+		return nil, errMemoryInvalidParameter
+	}
+	if _, exists := a.users[email]; exists {
+		return nil, errMemoryEmailInUse
+	}
+
+	code, err := a.issueCode(email, "email change", memoryEmailCodeTTL)
+	if err != nil {
+		return nil, err
+	}
+	user.pendingEmail = email
+	user.emailCode = code
+
+	return &entity.Email{Email: maskEmail(email)}, nil
+}
+
+func (a *MemoryAdapter) VerifyEmail(ctx context.Context, accessToken string, code string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+	if user.pendingEmail == "" {
+		return errMemoryInvalidParameter
+	}
+	if err := checkCode(user.emailCode, code); err != nil {
+		return err
+	}
+	// Someone may have registered the address while the code was in flight.
+	if _, exists := a.users[user.pendingEmail]; exists {
+		return errMemoryEmailInUse
+	}
+
+	oldEmail := user.email
+	user.updatedAt = time.Now()
+	user.email = user.pendingEmail
+	user.pendingEmail = ""
+	user.emailCode = nil
+
+	delete(a.users, oldEmail)
+	a.users[user.email] = user
+	a.subs[user.sub] = user.email
+	for token, stored := range a.refreshTokens {
+		if stored.email == oldEmail {
+			stored.email = user.email
+			a.refreshTokens[token] = stored
+		}
+	}
+
+	return nil
+}
+
+func (a *MemoryAdapter) ResendEmailVerification(ctx context.Context, accessToken string) (*entity.Email, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, err := a.userFromAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if user.pendingEmail == "" {
+		return nil, errMemoryInvalidParameter
+	}
+
+	code, err := a.issueCode(user.pendingEmail, "email change", memoryEmailCodeTTL)
+	if err != nil {
+		return nil, err
+	}
+	user.emailCode = code
+
+	return &entity.Email{Email: maskEmail(user.pendingEmail)}, nil
+}
+
+func (a *MemoryAdapter) issueCode(email, purpose string, ttl time.Duration) (*memoryCode, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, err
+	}
+
+	code := &memoryCode{
+		value:     fmt.Sprintf("%06d", n.Int64()),
+		expiresAt: time.Now().Add(ttl),
+	}
+	a.logger.Info("Issued "+purpose+" code", zap.String("Email", email), zap.String("Code", code.value))
+
+	return code, nil
+}
+
+func (a *MemoryAdapter) issueTokens(user *memoryUser, withRefreshToken bool) (*entity.LoginResult, error) {
+	now := time.Now()
+
+	accessJTI, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	accessClaims := jwt.MapClaims{
+		"sub":       user.sub,
+		"iss":       memoryIssuer,
+		"client_id": a.clientID,
+		"token_use": "access",
+		"scope":     "aws.cognito.signin.user.admin",
+		"username":  user.sub,
+		"auth_time": now.Unix(),
+		"iat":       now.Unix(),
+		"exp":       now.Add(memoryTokenTTL).Unix(),
+		"jti":       accessJTI,
+	}
+	idClaims := jwt.MapClaims{
+		"sub":              user.sub,
+		"iss":              memoryIssuer,
+		"aud":              a.clientID,
+		"token_use":        "id",
+		"cognito:username": user.sub,
+		"email":            user.email,
+		"email_verified":   user.confirmed,
+		"name":             user.name,
+		"auth_time":        now.Unix(),
+		"iat":              now.Unix(),
+		"exp":              now.Add(memoryTokenTTL).Unix(),
+	}
+	// Like Cognito, both tokens list the groups, and only when there are any.
+	if len(user.groups) > 0 {
+		accessClaims["cognito:groups"] = user.groups
+		idClaims["cognito:groups"] = user.groups
+	}
+
+	accessToken, err := a.sign(accessClaims)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := a.sign(idClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn := int32(memoryTokenTTL.Seconds())
+	tokenType := "Bearer"
+	result := &entity.LoginResult{
+		AccessToken: &accessToken,
+		ExpiresIn:   &expiresIn,
+		IdToken:     &idToken,
+		TokenType:   &tokenType,
+	}
+
+	if withRefreshToken {
+		refreshToken, err := randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		a.refreshTokens[refreshToken] = memoryRefreshToken{
+			email:     user.email,
+			expiresAt: now.Add(memoryRefreshTokenTTL),
+		}
+		result.RefreshToken = &refreshToken
+	}
+
+	return result, nil
+}
+
+func (a *MemoryAdapter) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = a.keyID
+	return token.SignedString(a.key)
+}
+
+// userFromAccessToken must be called with a.mu held.
+func (a *MemoryAdapter) userFromAccessToken(accessToken string) (*memoryUser, error) {
+	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		return &a.key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithIssuer(memoryIssuer))
+	if err != nil {
+		return nil, errMemoryNotAuthorized
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["token_use"] != "access" {
+		return nil, errMemoryNotAuthorized
+	}
+
+	sub, _ := claims["sub"].(string)
+	user, ok := a.users[a.subs[sub]]
+	if !ok || user.disabled {
+		return nil, errMemoryNotAuthorized
+	}
+
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil || issuedAt.Before(user.signedOutAt) {
+		return nil, errMemoryNotAuthorized
+	}
+	return user, nil
+}
+
+func (g *memoryGroup) toGroup(name string) entity.Group {
+	return entity.Group{
+		Name:        name,
+		Description: g.description,
+		Precedence:  g.precedence,
+		CreatedAt:   g.createdAt,
+		UpdatedAt:   g.updatedAt,
+	}
+}
+
+// allAttributes returns the user's attributes the way Cognito lists them.
+func (u *memoryUser) allAttributes() map[string]string {
+	attributes := map[string]string{
+		"sub":            u.sub,
+		"name":           u.name,
+		"email":          u.email,
+		"email_verified": strconv.FormatBool(u.confirmed),
+	}
+	for name, value := range u.attributes {
+		attributes[name] = value
+	}
+	return attributes
+}
+
+func (u *memoryUser) status() string {
+	switch {
+	case u.resetRequired:
+		return entity.UserStatusResetRequired
+	case u.mustChangePassword:
+		return entity.UserStatusForceChangePassword
+	case u.confirmed:
+		return entity.UserStatusConfirmed
+	default:
+		return entity.UserStatusUnconfirmed
+	}
+}
+
+func (u *memoryUser) adminUser() entity.AdminUser {
+	return entity.AdminUser{
+		Username:   u.sub,
+		Enabled:    !u.disabled,
+		Status:     u.status(),
+		CreatedAt:  u.createdAt,
+		UpdatedAt:  u.updatedAt,
+		Attributes: u.allAttributes(),
+	}
+}
+
+// matches applies a ListUsers filter, including the pseudo attributes Cognito
+// accepts besides the user's own.
+func (u *memoryUser) matches(filter entity.UserFilter) bool {
+	if filter.Attribute == "" {
+		return true
+	}
+
+	var value string
+	switch filter.Attribute {
+	case "username":
+		value = u.sub
+	case "cognito:user_status":
+		value = u.status()
+	case "status":
+		value = "Enabled"
+		if u.disabled {
+			value = "Disabled"
+		}
+	default:
+		value = u.allAttributes()[filter.Attribute]
+	}
+
+	if filter.Prefix {
+		return strings.HasPrefix(value, filter.Value)
+	}
+	return value == filter.Value
+}
+
+func checkCode(code *memoryCode, value string) error {
+	if code == nil || time.Now().After(code.expiresAt) {
+		return errMemoryExpiredCode
+	}
+	if code.value != value {
+		return errMemoryCodeMismatch
+	}
+	return nil
+}
+
+var (
+	errMemoryInvalidParameter = &utils.CustomError{Message: "Invalid parameter", Status: http.StatusBadRequest, Code: utils.ErrCodeInvalidParameter}
+	errMemoryUsernameExists   = &utils.CustomError{Message: "Username already exists", Status: http.StatusConflict, Code: utils.ErrCodeUsernameExists}
+	errMemoryNotAuthorized    = &utils.CustomError{Message: "Not Authorized", Status: http.StatusUnauthorized, Code: utils.ErrCodeNotAuthorized}
+	errMemoryUserNotFound     = &utils.CustomError{Message: "User not found", Status: http.StatusNotFound, Code: utils.ErrCodeUserNotFound}
+	errMemoryUserNotConfirmed = &utils.CustomError{Message: "User not confirm", Status: http.StatusForbidden, Code: utils.ErrCodeUserNotConfirmed}
+	errMemoryCodeMismatch     = &utils.CustomError{Message: "Invalid verification code", Status: http.StatusBadRequest, Code: utils.ErrCodeCodeMismatch}
+	errMemoryExpiredCode      = &utils.CustomError{Message: "Verification code expired", Status: http.StatusBadRequest, Code: utils.ErrCodeCodeExpired}
+
+	errMemoryRefreshTokenInvalid  = &utils.CustomError{Message: "Refresh token expired or revoked", Status: http.StatusUnauthorized, Code: utils.ErrCodeRefreshTokenInvalid}
+	errMemoryInvalidSession       = &utils.CustomError{Message: "Session expired", Status: http.StatusUnauthorized, Code: utils.ErrCodeSessionExpired}
+	errMemoryUnsupportedChallenge = &utils.CustomError{Message: "Unsupported challenge", Status: http.StatusBadRequest, Code: utils.ErrCodeUnsupportedChallenge}
+
+	errMemorySoftwareTokenMismatch = &utils.CustomError{Message: "Invalid software token code", Status: http.StatusBadRequest, Code: utils.ErrCodeMFACodeMismatch}
+	errMemoryEmailInUse            = &utils.CustomError{Message: "Email already in use", Status: http.StatusConflict, Code: utils.ErrCodeEmailInUse}
+	errMemoryPasswordResetRequired = &utils.CustomError{Message: "Password reset required", Status: http.StatusForbidden, Code: utils.ErrCodePasswordResetRequired}
+	errMemoryUnsupportedUserState  = &utils.CustomError{Message: "Unsupported user state", Status: http.StatusBadRequest, Code: utils.ErrCodeUnsupportedUserState}
+	errMemoryGroupExists           = &utils.CustomError{Message: "Group already exists", Status: http.StatusConflict, Code: utils.ErrCodeGroupExists}
+	errMemoryGroupNotFound         = &utils.CustomError{Message: "Group not found", Status: http.StatusNotFound, Code: utils.ErrCodeGroupNotFound}
+)
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// maskEmail renders an address the way Cognito reports a code delivery destination.
+func maskEmail(email string) string {
+	local, domain, found := strings.Cut(email, "@")
+	if !found || local == "" || domain == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain[:1] + "***"
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
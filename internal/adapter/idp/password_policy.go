@@ -0,0 +1,35 @@
+package idp
+
+import "github.com/Zeta-Manu/manu-auth/pkg/password"
+
+type PasswordPolicy struct {
+	MinimumLength    int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireNumbers   bool
+	RequireSymbols   bool
+}
+
+// DefaultPasswordPolicy mirrors the policy of a freshly created Cognito user pool.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinimumLength:    8,
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireNumbers:   true,
+		RequireSymbols:   true,
+	}
+}
+
+// Violation names the first rule password breaks, worded like Cognito's
+// InvalidPasswordException, or returns "" when it satisfies the policy.
+func (p PasswordPolicy) Violation(pw string) string {
+	violation, _ := password.Policy{
+		MinimumLength:    p.MinimumLength,
+		RequireLowercase: p.RequireLowercase,
+		RequireUppercase: p.RequireUppercase,
+		RequireNumbers:   p.RequireNumbers,
+		RequireSymbols:   p.RequireSymbols,
+	}.ClassViolation(pw)
+	return violation.Message
+}
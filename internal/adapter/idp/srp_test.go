@@ -0,0 +1,82 @@
+package idp
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func hexInt(t *testing.T, h string) *big.Int {
+	t.Helper()
+	n, ok := new(big.Int).SetString(strings.ReplaceAll(h, " ", ""), 16)
+	if !ok {
+		t.Fatalf("bad hex %q", h)
+	}
+	return n
+}
+
+// TestSRPPremasterSecretRFC5054 checks the client side of SRP against the
+// 1024-bit test vector in RFC 5054, appendix B.
+func TestSRPPremasterSecretRFC5054(t *testing.T) {
+	N := hexInt(t, "EEAF0AB9 ADB38DD6 9C33F80A FA8FC5E8 60726187 75FF3C0B 9EA2314C"+
+		"9C256576 D674DF74 96EA81D3 383B4813 D692C6E0 E0D5D8E2 50B98BE4"+
+		"8E495C1D 6089DAD1 5DC7D7B4 6154D6B6 CE8EF4AD 69B15D49 82559B29"+
+		"7BCF1885 C529F566 660E57EC 68EDBC3C 05726CC0 2FD4CBF4 976EAA9A"+
+		"FD5138FE 8376435B 9FC61D2F C0EB06E3")
+	g := big.NewInt(2)
+	k := hexInt(t, "7556AA04 5AEF2CDD 07ABAF0F 665C3E81 8913186F")
+	x := hexInt(t, "94B7555A ABE9127C C58CCF49 93DB6CF8 4D16C124")
+	a := hexInt(t, "60975527 035CF2AD 1989806F 0407210B C81EDC04 E2762A56 AFD529DD DA2D4393")
+	A := hexInt(t, "61D5E490 F6F1B795 47B0704C 436F523D D0E560F0 C64115BB 72557EC4"+
+		"4352E890 3211C046 92272D8B 2D1A5358 A2CF1B6E 0BFCF99F 921530EC"+
+		"8E393561 79EAE45E 42BA92AE ACED8251 71E1E8B9 AF6D9C03 E1327F44"+
+		"BE087EF0 6530E69F 66615261 EEF54073 CA11CF58 58F0EDFD FE15EFEA"+
+		"B349EF5D 76988A36 72FAC47B 0769447B")
+	B := hexInt(t, "BD0C6151 2C692C0C B6D041FA 01BB152D 4916A1E7 7AF46AE1 05393011"+
+		"BAF38964 DC46A067 0DD125B9 5A981652 236F99D9 B681CBF8 7837EC99"+
+		"6C6DA044 53728610 D0C6DDB5 8B318885 D7D82C7F 8DEB75CE 7BD4FBAA"+
+		"37089E6F 9C6059F3 88838E7A 00030B33 1EB76840 910440B1 B27AAEAE"+
+		"EB4012B7 D7665238 A8E3FB00 4B117B58")
+	u := hexInt(t, "CE38B959 3487DA98 554ED47D 70A7AE5F 462EF019")
+	want := hexInt(t, "B0DC82BA BCF30674 AE450C02 87745E79 90A3381F 63B387AA F271A10D"+
+		"233861E3 59B48220 F7C4693C 9AE12B0A 6F67809F 0876E2D0 13800D6C"+
+		"41BB59B6 D5979B5C 00A172B4 A2A5903A 0BDCAF8A 709585EB 2AFAFA8F"+
+		"3499B200 210DCC1F 10EB3394 3CD67FC8 8A2F39A4 BE5BEC4E C0A3212D"+
+		"C346D7E4 74B29EDE 8A469FFE CA686E5A")
+
+	if got := new(big.Int).Exp(g, a, N); got.Cmp(A) != 0 {
+		t.Fatalf("A = %x, want %x", got, A)
+	}
+	if got := srpPremasterSecret(N, g, k, B, a, u, x); got.Cmp(want) != 0 {
+		t.Fatalf("S = %x, want %x", got, want)
+	}
+}
+
+// TestHKDFSHA256RFC5869 checks the first block of test case 1 in RFC 5869,
+// appendix A.
+func TestHKDFSHA256RFC5869(t *testing.T) {
+	ikm := mustDecodeHex("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt := mustDecodeHex("000102030405060708090a0b0c")
+	info := mustDecodeHex("f0f1f2f3f4f5f6f7f8f9")
+	want := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf"
+
+	if got := hex.EncodeToString(hkdfSHA256(ikm, salt, info, 32)); got != want {
+		t.Fatalf("OKM = %s, want %s", got, want)
+	}
+}
+
+func TestPadHex(t *testing.T) {
+	tests := map[int64]string{
+		0x1:    "01",
+		0x7f:   "7f",
+		0x80:   "0080",
+		0xabc:  "0abc",
+		0xfedc: "00fedc",
+	}
+	for n, want := range tests {
+		if got := padHex(big.NewInt(n)); got != want {
+			t.Errorf("padHex(%#x) = %s, want %s", n, got, want)
+		}
+	}
+}
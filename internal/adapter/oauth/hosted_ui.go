@@ -0,0 +1,239 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+const DefaultStateTTL = 10 * time.Minute
+
+// StateCookie carries the sealed state and PKCE verifier from authorize to
+// callback. It is only sent to the OAuth endpoints.
+const (
+	StateCookie     = "manu_oauth_state"
+	StateCookiePath = "/api/v2/oauth"
+)
+
+var (
+	errRedirectURINotAllowed = &utils.CustomError{
+		Message: "redirect_uri is not allowed",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeRedirectURINotAllowed,
+	}
+	errInvalidState = &utils.CustomError{
+		Message: "Invalid or expired state",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeInvalidState,
+	}
+	errInvalidGrant = &utils.CustomError{
+		Message: "Invalid authorization code",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeInvalidGrant,
+	}
+	errTokenEndpoint = &utils.CustomError{
+		Message: "Token exchange with the hosted UI failed",
+		Status:  http.StatusBadGateway,
+		Code:    utils.ErrCodeIdPUnavailable,
+	}
+)
+
+type Config struct {
+	// Domain is the user pool domain, e.g. https://manu.auth.eu-west-1.amazoncognito.com.
+	Domain       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURIs lists the callback URLs registered on the app client. The
+	// first one is used when a request does not ask for a specific one.
+	RedirectURIs []string
+	Scopes       []string
+	StateTTL     time.Duration
+	// CookieSecret keys the state cookie and must be shared by all replicas.
+	CookieSecret string
+	HTTPClient   *http.Client
+}
+
+// HostedUI runs the authorization code flow with PKCE against the Cognito
+// hosted UI, which also fronts federated providers such as Google and Apple.
+type HostedUI struct {
+	cfg    Config
+	states *sealer
+}
+
+func NewHostedUI(cfg Config) (*HostedUI, error) {
+	cfg.Domain = strings.TrimRight(cfg.Domain, "/")
+	if !strings.Contains(cfg.Domain, "://") {
+		cfg.Domain = "https://" + cfg.Domain
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.StateTTL <= 0 {
+		cfg.StateTTL = DefaultStateTTL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	states, err := newSealer(cfg.CookieSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &HostedUI{
+		cfg:    cfg,
+		states: states,
+	}, nil
+}
+
+// StateTTL is how long a started login stays valid, and the state cookie's
+// lifetime.
+func (h *HostedUI) StateTTL() time.Duration {
+	return h.cfg.StateTTL
+}
+
+// AuthorizeURL starts a login and returns the hosted UI URL to send the user
+// to, along with the value of StateCookie to set in their browser.
+// identityProvider (e.g. Google or SignInWithApple) skips the provider
+// selection page when set.
+func (h *HostedUI) AuthorizeURL(redirectURI, identityProvider string) (string, string, error) {
+	redirectURI, err := h.redirectURI(redirectURI)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	cookie, err := h.states.seal(pendingAuthorization{
+		State:        state,
+		CodeVerifier: codeVerifier,
+		RedirectURI:  redirectURI,
+		ExpiresAt:    time.Now().Add(h.cfg.StateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", h.cfg.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(h.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if identityProvider != "" {
+		query.Set("identity_provider", identityProvider)
+	}
+
+	return h.cfg.Domain + "/oauth2/authorize?" + query.Encode(), cookie, nil
+}
+
+// Exchange finishes the login started with state by trading code for tokens.
+// cookie is the StateCookie the browser sent back; a callback opened in any
+// other browser than the one that started the login is rejected.
+func (h *HostedUI) Exchange(ctx context.Context, code, state, cookie string) (*entity.LoginResult, error) {
+	pending, err := h.states.open(cookie, state)
+	if err != nil {
+		return nil, withCause(errInvalidState, err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", h.cfg.ClientID)
+	form.Set("code", code)
+	form.Set("redirect_uri", pending.RedirectURI)
+	form.Set("code_verifier", pending.CodeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.Domain+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if h.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(h.cfg.ClientID), url.QueryEscape(h.cfg.ClientSecret))
+	}
+
+	resp, err := h.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errTokenEndpoint
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		IdToken      string `json:"id_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int32  `json:"expires_in"`
+		Error        string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errTokenEndpoint
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Error == "invalid_grant" {
+			return nil, errInvalidGrant
+		}
+		return nil, errTokenEndpoint
+	}
+
+	result := &entity.LoginResult{
+		AccessToken: &body.AccessToken,
+		ExpiresIn:   &body.ExpiresIn,
+		IdToken:     &body.IdToken,
+		TokenType:   &body.TokenType,
+	}
+	// Cognito leaves it out, e.g. when the app client disallows refresh tokens.
+	if body.RefreshToken != "" {
+		result.RefreshToken = &body.RefreshToken
+	}
+	return result, nil
+}
+
+func withCause(mapped *utils.CustomError, err error) error {
+	result := *mapped
+	result.Err = err
+	return &result
+}
+
+func (h *HostedUI) redirectURI(requested string) (string, error) {
+	if len(h.cfg.RedirectURIs) == 0 {
+		return "", errors.New("oauth: no redirect URIs configured")
+	}
+	if requested == "" {
+		return h.cfg.RedirectURIs[0], nil
+	}
+	for _, allowed := range h.cfg.RedirectURIs {
+		if requested == allowed {
+			return requested, nil
+		}
+	}
+	return "", errRedirectURINotAllowed
+}
+
+// randomToken returns 32 random bytes, base64url encoded: 43 characters, as
+// RFC 7636 recommends for code verifiers.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,89 @@
+package oauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// pendingAuthorization is what the callback needs to finish a login started
+// by /oauth/authorize. It travels in a cookie sealed with AES-GCM, so it binds
+// the flow to the browser that started it and any replica can finish it.
+type pendingAuthorization struct {
+	State        string    `json:"s"`
+	CodeVerifier string    `json:"v"`
+	RedirectURI  string    `json:"r"`
+	ExpiresAt    time.Time `json:"e"`
+}
+
+// sealer encrypts and authenticates pending authorizations.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+// newSealer derives the key from secret. An empty secret gets a random key,
+// which only works while a single replica serves both authorize and callback.
+func newSealer(secret string) (*sealer, error) {
+	var key [32]byte
+	if secret == "" {
+		if _, err := rand.Read(key[:]); err != nil {
+			return nil, err
+		}
+	} else {
+		key = sha256.Sum256([]byte(secret))
+	}
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+func (s *sealer) seal(pending pendingAuthorization) (string, error) {
+	plaintext, err := json.Marshal(pending)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// open returns the pending authorization in value if it is authentic,
+// unexpired and was started with state.
+func (s *sealer) open(value, state string) (pendingAuthorization, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return pendingAuthorization{}, errors.New("oauth: malformed state cookie")
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return pendingAuthorization{}, err
+	}
+
+	var pending pendingAuthorization
+	if err := json.Unmarshal(plaintext, &pending); err != nil {
+		return pendingAuthorization{}, err
+	}
+	if time.Now().After(pending.ExpiresAt) {
+		return pendingAuthorization{}, errors.New("oauth: state expired")
+	}
+	if subtle.ConstantTimeCompare([]byte(pending.State), []byte(state)) != 1 {
+		return pendingAuthorization{}, errors.New("oauth: state does not match cookie")
+	}
+	return pending, nil
+}
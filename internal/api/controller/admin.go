@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/Zeta-Manu/manu-auth/internal/adapter/idp"
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/audit"
+	"github.com/Zeta-Manu/manu-auth/pkg/lockout"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+// userFilterPattern matches the ListUsers filter syntax Cognito supports:
+// attribute = "value" or attribute ^= "value".
+var userFilterPattern = regexp.MustCompile(`^\s*([\w:]+)\s*(\^?=)\s*"([^"]*)"\s*$`)
+
+var errInvalidFilter = &utils.CustomError{
+	Message: `Invalid filter, expected attribute = "value" or attribute ^= "value"`,
+	Status:  http.StatusBadRequest,
+	Code:    utils.ErrCodeInvalidFilter,
+}
+
+type AdminController struct {
+	logger     *zap.Logger
+	idpAdapter idp.IdentityProvider
+	lockouts   *lockout.Tracker
+}
+
+func NewAdminController(idpAdapter idp.IdentityProvider, lockouts *lockout.Tracker, logger *zap.Logger) *AdminController {
+	return &AdminController{
+		idpAdapter: idpAdapter,
+		lockouts:   lockouts,
+		logger:     logger,
+	}
+}
+
+// @Summary List users
+// @Description List or search users, a page at a time
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param filter query string false "e.g. email ^= \"jane\" or cognito:user_status = \"UNCONFIRMED\""
+// @Param limit query int false "Page size, at most 60"
+// @Param pagination_token query string false "Token from the previous page"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.UserList}
+// @Failure 400 {object} entity.Problem "Invalid filter"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users [get]
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	var filter entity.UserFilter
+	if raw := c.Query("filter"); raw != "" {
+		match := userFilterPattern.FindStringSubmatch(raw)
+		if match == nil {
+			c.Error(errInvalidFilter)
+			return
+		}
+		filter = entity.UserFilter{Attribute: match[1], Prefix: match[2] == "^=", Value: match[3]}
+	}
+
+	limit, ok := parseLimit(c, 60)
+	if !ok {
+		return
+	}
+
+	result, err := ac.idpAdapter.ListUsers(c, filter, limit, c.Query("pagination_token"))
+	if err != nil {
+		respondError(c, ac.logger, err, "Admin list users failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Summary Get user
+// @Description Show one user with all attributes
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.AdminUser}
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username} [get]
+func (ac *AdminController) GetUser(c *gin.Context) {
+	result, err := ac.idpAdapter.AdminGetUser(c, c.Param("username"))
+	if err != nil {
+		respondError(c, ac.logger, err, "Admin get user failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Summary Disable user
+// @Description Block the user from logging in
+// @Tags Admin
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Success 200
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username}/disable [post]
+func (ac *AdminController) DisableUser(c *gin.Context) {
+	ac.userAction(c, "disable user", ac.idpAdapter.AdminDisableUser)
+}
+
+// @Summary Enable user
+// @Description Allow a disabled user to log in again
+// @Tags Admin
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Success 200
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username}/enable [post]
+func (ac *AdminController) EnableUser(c *gin.Context) {
+	ac.userAction(c, "enable user", ac.idpAdapter.AdminEnableUser)
+}
+
+// @Summary Force password reset
+// @Description Invalidate the password and send the user a reset code to use with /confirm-forgot
+// @Tags Admin
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Success 200
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username}/reset-password [post]
+func (ac *AdminController) ResetUserPassword(c *gin.Context) {
+	ac.userAction(c, "reset user password", ac.idpAdapter.AdminResetUserPassword)
+}
+
+// @Summary Resend invitation
+// @Description Send a new temporary password to a user who has not logged in yet
+// @Tags Admin
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Success 200
+// @Failure 400 {object} entity.Problem "Unsupported user state"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username}/resend-invitation [post]
+func (ac *AdminController) ResendInvitation(c *gin.Context) {
+	ac.userAction(c, "resend invitation", ac.idpAdapter.AdminResendInvitation)
+}
+
+// @Summary Delete user
+// @Description Delete the user immediately, without a grace period
+// @Tags Admin
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Success 200
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username} [delete]
+func (ac *AdminController) DeleteUser(c *gin.Context) {
+	ac.userAction(c, "delete user", ac.idpAdapter.AdminDeleteUser)
+}
+
+// userAction runs an admin operation on the user named in the path. The
+// caller is logged, since these calls bypass the user's own credentials.
+func (ac *AdminController) userAction(c *gin.Context, action string, run func(ctx context.Context, username string) error) {
+	username := c.Param("username")
+	audit.SetSubject(c, username)
+	if err := run(c, username); err != nil {
+		respondError(c, ac.logger, err, "Admin "+action+" failed")
+		return
+	}
+
+	ac.logger.Info("Admin "+action+" successfully", zap.String("Admin", adminName(c)), zap.String("Username", username))
+	c.Status(http.StatusOK)
+}
+
+// parseLimit reads the optional page size, recording a 400 when it is invalid.
+func parseLimit(c *gin.Context, max int64) (int32, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || limit < 1 || limit > max {
+		invalidParameter(c, fmt.Sprintf("limit must be between 1 and %d", max))
+		return 0, false
+	}
+	return int32(limit), true
+}
@@ -3,24 +3,44 @@ package controller
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 
 	"github.com/Zeta-Manu/manu-auth/internal/adapter/idp"
 	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
-	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+	"github.com/Zeta-Manu/manu-auth/pkg/audit"
+	"github.com/Zeta-Manu/manu-auth/pkg/deletion"
+	"github.com/Zeta-Manu/manu-auth/pkg/lockout"
+	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+	"github.com/Zeta-Manu/manu-auth/pkg/password"
+	"github.com/Zeta-Manu/manu-auth/pkg/revocation"
+	"github.com/Zeta-Manu/manu-auth/pkg/totp"
 )
 
 type UserController struct {
-	logger     *zap.Logger
-	idpAdapter idp.CognitoAdapter
+	logger      *zap.Logger
+	idpAdapter  idp.IdentityProvider
+	revocations *revocation.List
+	deletions   *deletion.Scheduler
+	lockouts    *lockout.Tracker
+	passwords   *password.Checker
+	mfaIssuer   string
+	profile     ProfileAttributes
 }
 
-func NewUserController(idpAdapter idp.CognitoAdapter, logger *zap.Logger) *UserController {
+func NewUserController(idpAdapter idp.IdentityProvider, revocations *revocation.List, deletions *deletion.Scheduler, lockouts *lockout.Tracker, passwords *password.Checker, mfaIssuer string, profile ProfileAttributes, logger *zap.Logger) *UserController {
 	return &UserController{
-		idpAdapter: idpAdapter,
-		logger:     logger,
+		idpAdapter:  idpAdapter,
+		revocations: revocations,
+		deletions:   deletions,
+		lockouts:    lockouts,
+		passwords:   passwords,
+		mfaIssuer:   mfaIssuer,
+		profile:     profile,
+		logger:      logger,
 	}
 }
 
@@ -31,30 +51,26 @@ func NewUserController(idpAdapter idp.CognitoAdapter, logger *zap.Logger) *UserC
 // @Produce		json
 // @Param			body	body		entity.UserRegistration											true	"User registration info"
 // @Success 200 {object} entity.ResponseWrapper
-// @Failure 400 {object} entity.ErrorWrapper "Invalid Password or Invalid Parameter"
-// @Failure 409 {object} entity.ErrorWrapper "Username Exists"
-// @Failure 500 {object} entity.ErrorWrapper
+// @Failure 400 {object} entity.Problem "Invalid Password or Invalid Parameter"
+// @Failure 409 {object} entity.Problem "Username Exists"
+// @Failure 500 {object} entity.Problem
 // @Router			/signup [post]
 func (uc *UserController) SignUp(c *gin.Context) {
 	var userRegistration entity.UserRegistration
-	if err := c.ShouldBindJSON(&userRegistration); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &userRegistration) {
+		return
+	}
+	audit.SetSubject(c, userRegistration.Email)
+	if !uc.checkPassword(c, "password", userRegistration.Password, userRegistration.Email, userRegistration.Name) {
 		return
 	}
 
 	result, err := uc.idpAdapter.Register(c, userRegistration)
 	if err != nil {
-		var customErr *utils.CustomError
-		if errors.As(err, &customErr) {
-			c.JSON(customErr.Status, gin.H{"error": customErr.Message})
-			uc.logger.Error("User registration failed", zap.String("error", customErr.Message))
-			return
-		}
-		uc.logger.Error("Failed to register user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	} else {
-		uc.logger.Info("User registered successfully", zap.String("Email", userRegistration.Email))
+		respondError(c, uc.logger, err, "User registration failed")
+		return
 	}
+	uc.logger.Info("User registered successfully", zap.String("Email", userRegistration.Email))
 
 	response := gin.H{
 		"data": result,
@@ -75,24 +91,17 @@ func (uc *UserController) SignUp(c *gin.Context) {
 // @Router /confirm [post]
 func (uc *UserController) ConfirmSignUp(c *gin.Context) {
 	var userRegistrationConfirm entity.UserRegistrationConfirm
-	if err := c.ShouldBindJSON(&userRegistrationConfirm); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &userRegistrationConfirm) {
 		return
 	}
+	audit.SetSubject(c, userRegistrationConfirm.Email)
 
 	err := uc.idpAdapter.ConfirmRegistration(c, userRegistrationConfirm)
 	if err != nil {
-		var customErr *utils.CustomError
-		if errors.As(err, &customErr) {
-			c.JSON(customErr.Status, gin.H{"error": customErr.Message})
-			uc.logger.Error("User confirm registration failed", zap.String("error", customErr.Message))
-			return
-		}
-		uc.logger.Error("Failed to confirm user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	} else {
-		uc.logger.Info("User confirm successfully", zap.String("Email", userRegistrationConfirm.Email))
+		respondError(c, uc.logger, err, "User confirm registration failed")
+		return
 	}
+	uc.logger.Info("User confirm successfully", zap.String("Email", userRegistrationConfirm.Email))
 
 	c.Status(http.StatusOK)
 }
@@ -104,23 +113,20 @@ func (uc *UserController) ConfirmSignUp(c *gin.Context) {
 // @Produce json
 // @Param email body entity.Email true "Email address to resend the confirmation code to"
 // @Success 200 {object} entity.ResponseWrapper
-// @Failure 500 {object} entity.ErrorWrapper
+// @Failure 500 {object} entity.Problem
 // @Router /resend-confirm [post]
 func (uc *UserController) ResendConfirmationCode(c *gin.Context) {
 	var email entity.Email
+	if !bindJSON(c, &email) {
+		return
+	}
+
 	result, err := uc.idpAdapter.ResendConfirmationCode(c, email.Email)
 	if err != nil {
-		var customErr *utils.CustomError
-		if errors.As(err, &customErr) {
-			c.JSON(customErr.Status, gin.H{"error": customErr.Message})
-			uc.logger.Error("User resend confirm registration code failed", zap.String("error", customErr.Message))
-			return
-		}
-		uc.logger.Error("Failed to resend confirm registraion code", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	} else {
-		uc.logger.Info("User resend confirm successfully", zap.String("Email", email.Email))
+		respondError(c, uc.logger, err, "User resend confirm registration code failed")
+		return
 	}
+	uc.logger.Info("User resend confirm successfully", zap.String("Email", email.Email))
 
 	response := gin.H{
 		"data": result,
@@ -136,30 +142,98 @@ func (uc *UserController) ResendConfirmationCode(c *gin.Context) {
 // @Produce		json
 // @Param			body	body		entity.UserLogin									true	"User login info"
 // @Success 200 {object} entity.ResponseWrapper{data=entity.LoginResult}
-// @Failure 400 {object} entity.ErrorWrapper "Invalid Password or Missing Parameter"
-// @Failure 401 {object} entity.ErrorWrapper "Not Authorized"
-// @Failure 403 {object} entity.ErrorWrapper "User Not Confirm"
-// @Failure 404 {object} entity.ErrorWrapper "User Not Found"
-// @Failure 500 {object} entity.ErrorWrapper
+// @Success 202 {object} entity.ResponseWrapper{data=entity.AuthChallenge} "Challenge to answer at /challenge"
+// @Failure 400 {object} entity.Problem "Invalid Password or Missing Parameter"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "User Not Confirm"
+// @Failure 429 {object} entity.Problem "Too many requests or failed login attempts, see Retry-After"
+// @Failure 500 {object} entity.Problem
 // @Router			/login [post]
 func (uc *UserController) LogIn(c *gin.Context) {
 	var userLogin entity.UserLogin
-	if err := c.ShouldBindJSON(&userLogin); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &userLogin) {
+		return
+	}
+	audit.SetSubject(c, userLogin.Email)
+	if !uc.checkLockout(c, userLogin.Email) {
 		return
 	}
-	result, err := uc.idpAdapter.Login(c, userLogin)
+
+	result, err := uc.login(c, userLogin)
+	uc.recordLogin(c, userLogin.Email, err)
 	if err != nil {
-		var customErr *utils.CustomError
-		if errors.As(err, &customErr) {
-			c.JSON(customErr.Status, gin.H{"error": customErr.Message})
-			uc.logger.Error("User login failed", zap.String("error", customErr.Message))
-			return
-		}
-		uc.logger.Error("Failed to login user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	} else {
-		uc.logger.Info("User login successfully", zap.String("Email", userLogin.Email))
+		respondError(c, uc.logger, err, "User login failed")
+		return
+	}
+	uc.logger.Info("User login successfully", zap.String("Email", userLogin.Email))
+
+	respondAuthResult(c, result)
+}
+
+// @Summary		Answer an authentication challenge
+// @Description	Respond to a challenge returned by /login, such as NEW_PASSWORD_REQUIRED or SOFTWARE_TOKEN_MFA, until tokens are issued
+// @Tags User
+// @Accept			json
+// @Produce		json
+// @Param			body	body		entity.ChallengeResponse									true	"Challenge answer"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.LoginResult}
+// @Success 202 {object} entity.ResponseWrapper{data=entity.AuthChallenge} "Next challenge"
+// @Failure 400 {object} entity.Problem "Invalid Password, Invalid Code or Unsupported Challenge"
+// @Failure 401 {object} entity.Problem "Not Authorized or Session Expired"
+// @Failure 500 {object} entity.Problem
+// @Router			/challenge [post]
+func (uc *UserController) RespondToChallenge(c *gin.Context) {
+	var challengeResponse entity.ChallengeResponse
+	if !bindJSON(c, &challengeResponse) {
+		return
+	}
+	audit.SetSubject(c, challengeResponse.Email)
+	audit.SetDetail(c, "challenge", challengeResponse.ChallengeName)
+	if challengeResponse.NewPassword != "" && !uc.checkPassword(c, "new_password", challengeResponse.NewPassword, challengeResponse.Email, challengeResponse.Attributes["name"]) {
+		return
+	}
+
+	result, err := uc.respondToChallenge(c, challengeResponse)
+	if err != nil {
+		respondError(c, uc.logger, err, "User challenge response failed")
+		return
+	}
+	uc.logger.Info("User challenge response successfully", zap.String("Email", challengeResponse.Email), zap.String("Challenge", challengeResponse.ChallengeName))
+
+	respondAuthResult(c, result)
+}
+
+// respondAuthResult writes tokens with 200, or the next challenge with 202.
+func respondAuthResult(c *gin.Context, result *entity.AuthResult) {
+	if result.Challenge != nil {
+		c.JSON(http.StatusAccepted, gin.H{"data": result.Challenge})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result.Tokens})
+}
+
+// @Summary		Refresh tokens
+// @Description	Exchange a refresh token for new access and ID tokens
+// @Tags User
+// @Accept			json
+// @Produce		json
+// @Param			body	body		entity.RefreshToken									true	"Refresh token, plus the access token for app clients with a secret"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.LoginResult}
+// @Failure 400 {object} entity.Problem "Missing Parameter"
+// @Failure 401 {object} entity.Problem "Refresh token expired or revoked"
+// @Failure 500 {object} entity.Problem
+// @Router			/refresh [post]
+func (uc *UserController) RefreshToken(c *gin.Context) {
+	var refreshToken entity.RefreshToken
+	if !bindJSON(c, &refreshToken) {
+		return
+	}
+
+	result, err := uc.idpAdapter.RefreshToken(c, refreshToken)
+	if err != nil {
+		respondError(c, uc.logger, err, "User refresh token failed")
+		return
 	}
 
 	response := gin.H{
@@ -176,29 +250,22 @@ func (uc *UserController) LogIn(c *gin.Context) {
 // @Produce json
 // @Param email body entity.Email true "Email address of the user"
 // @Success 200 {object} entity.ResponseWrapper
-// @Failure 400 {object} entity.ErrorWrapper
-// @Failure 500 {object} entity.ErrorWrapper
+// @Failure 400 {object} entity.Problem
+// @Failure 500 {object} entity.Problem
 // @Router /forgot-password [post]
 func (uc *UserController) ForgotPassword(c *gin.Context) {
 	var email entity.Email
-	if err := c.ShouldBindJSON(&email); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &email) {
 		return
 	}
+	audit.SetSubject(c, email.Email)
 
 	result, err := uc.idpAdapter.ForgotPassword(c, email.Email)
 	if err != nil {
-		var customErr *utils.CustomError
-		if errors.As(err, &customErr) {
-			c.JSON(customErr.Status, gin.H{"error": customErr.Message})
-			uc.logger.Error("User forgot password", zap.String("error", customErr.Message))
-			return
-		}
-		uc.logger.Error("Failed to forgot password", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	} else {
-		uc.logger.Info("User forgot password successfully", zap.String("Email", email.Email))
+		respondError(c, uc.logger, err, "User forgot password")
+		return
 	}
+	uc.logger.Info("User forgot password successfully", zap.String("Email", email.Email))
 
 	response := gin.H{
 		"data": result,
@@ -214,33 +281,198 @@ func (uc *UserController) ForgotPassword(c *gin.Context) {
 // @Produce json
 // @Param email body entity.UserResetPassword true "Email address of the user"
 // @Success 200 {object} entity.ResponseWrapper
-// @Failure 400 {object} entity.ErrorWrapper
-// @Failure 500 {object} entity.ErrorWrapper
+// @Failure 400 {object} entity.Problem
+// @Failure 500 {object} entity.Problem
 // @Router /confirm-forgot [post]
 func (uc *UserController) ConfirmForgotPassword(c *gin.Context) {
 	var userResetPassword entity.UserResetPassword
-	if err := c.ShouldBindJSON(&userResetPassword); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &userResetPassword) {
+		return
+	}
+	audit.SetSubject(c, userResetPassword.Email)
+	if !uc.checkPassword(c, "new_password", userResetPassword.NewPassword, userResetPassword.Email, "") {
 		return
 	}
 
 	err := uc.idpAdapter.ConfirmForgotPassword(c, userResetPassword)
 	if err != nil {
-		var customErr *utils.CustomError
-		if errors.As(err, &customErr) {
-			c.JSON(customErr.Status, gin.H{"error": customErr.Message})
-			uc.logger.Error("User confirm forgot password failed", zap.String("error", customErr.Message))
-			return
-		}
-		uc.logger.Error("Failed to confirm forgot password", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	} else {
-		uc.logger.Info("User confirm forgot password successfully", zap.String("Email", userResetPassword.Email))
+		respondError(c, uc.logger, err, "User confirm forgot password failed")
+		return
+	}
+	uc.logger.Info("User confirm forgot password successfully", zap.String("Email", userResetPassword.Email))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary Get own profile
+// @Description Return the readable attributes of the authenticated user
+// @Tags Profile
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.UserProfile}
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /me [get]
+func (uc *UserController) GetProfile(c *gin.Context) {
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	user, err := uc.idpAdapter.GetUser(c, token.(string))
+	if err != nil {
+		respondError(c, uc.logger, err, "User get profile failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": uc.profile.profile(user)})
+}
+
+// @Summary Update own profile
+// @Description Update writable attributes of the authenticated user and return the resulting profile
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param body body entity.UpdateProfile true "Attributes to set"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.UserProfile}
+// @Failure 400 {object} entity.Problem "Attribute is not writable or Invalid Parameter"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /me [patch]
+func (uc *UserController) UpdateProfile(c *gin.Context) {
+	var updateProfile entity.UpdateProfile
+	if !bindJSON(c, &updateProfile) {
+		return
+	}
+
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	err := uc.profile.checkWritable(updateProfile.Attributes)
+	if err == nil {
+		err = uc.idpAdapter.UpdateUserAttributes(c, token.(string), updateProfile.Attributes)
+	}
+	var user *entity.User
+	if err == nil {
+		user, err = uc.idpAdapter.GetUser(c, token.(string))
+	}
+	if err != nil {
+		respondError(c, uc.logger, err, "User update profile failed")
+		return
+	}
+	uc.logger.Info("User update profile successfully")
+
+	c.JSON(http.StatusOK, gin.H{"data": uc.profile.profile(user)})
+}
+
+// @Summary Change email
+// @Description Send a verification code to a new email. The current email stays in use until the code is verified
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param email body entity.Email true "New email address"
+// @Success 202 {object} entity.ResponseWrapper{data=entity.Email}
+// @Failure 400 {object} entity.Problem "Invalid Parameter"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 409 {object} entity.Problem "Email already in use"
+// @Failure 500 {object} entity.Problem
+// @Failure 501 {object} entity.Problem "Email changes are not enabled for the user pool"
+// @Security BearerAuth
+// @Router /me/email [post]
+func (uc *UserController) ChangeEmail(c *gin.Context) {
+	var email entity.Email
+	if !bindJSON(c, &email) {
+		return
+	}
+	audit.SetDetail(c, "new_email", email.Email)
+
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	result, err := uc.idpAdapter.ChangeEmail(c, token.(string), email.Email)
+	if err != nil {
+		respondError(c, uc.logger, err, "User change email failed")
+		return
+	}
+	uc.logger.Info("User change email requested")
+
+	c.JSON(http.StatusAccepted, gin.H{"data": result})
+}
+
+// @Summary Verify new email
+// @Description Verify the new email with the code sent to it, which makes it the login email
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param body body entity.VerifyEmail true "Verification code"
+// @Success 200
+// @Failure 400 {object} entity.Problem "Invalid or expired code"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 409 {object} entity.Problem "Email already in use"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /me/email/verify [post]
+func (uc *UserController) VerifyEmail(c *gin.Context) {
+	var verifyEmail entity.VerifyEmail
+	if !bindJSON(c, &verifyEmail) {
+		return
+	}
+
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	err := uc.idpAdapter.VerifyEmail(c, token.(string), verifyEmail.Code)
+	if err != nil {
+		respondError(c, uc.logger, err, "User verify email failed")
+		return
 	}
+	uc.logger.Info("User verify email successfully")
 
 	c.Status(http.StatusOK)
 }
 
+// @Summary Resend email verification
+// @Description Send a new verification code to the pending email
+// @Tags Profile
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.Email}
+// @Failure 400 {object} entity.Problem "Invalid Parameter"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /me/email/resend [post]
+func (uc *UserController) ResendEmailVerification(c *gin.Context) {
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	result, err := uc.idpAdapter.ResendEmailVerification(c, token.(string))
+	if err != nil {
+		respondError(c, uc.logger, err, "User resend email verification failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
 // @Summary Change user password
 // @Description Change the password for the authenticated user
 // @Tags User
@@ -249,54 +481,239 @@ func (uc *UserController) ConfirmForgotPassword(c *gin.Context) {
 // @Param Authorization header string true "Bearer {token}"
 // @Param body body entity.UserChangePassword true "User change password info"
 // @Success  200
-// @Failure  400 {object} entity.ErrorWrapper
-// @Failure  401 {object} entity.ErrorWrapper "Not Authorized"
-// @Failure  500 {object} entity.ErrorWrapper
+// @Failure  400 {object} entity.Problem
+// @Failure  401 {object} entity.Problem "Not Authorized"
+// @Failure  500 {object} entity.Problem
 // @Security BearerAuth
 // @Router /change-password [post]
 func (uc *UserController) ChangePassword(c *gin.Context) {
 	var userChangePassword entity.UserChangePassword
-	if err := c.ShouldBindJSON(&userChangePassword); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &userChangePassword) {
 		return
 	}
 
 	token, exists := c.Get("token")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(errUnauthorized)
+		return
+	}
+
+	// The similarity checks need the email and name of the account.
+	user, err := uc.idpAdapter.GetUser(c, token.(string))
+	if err != nil {
+		respondError(c, uc.logger, err, "User change password failed")
+		return
+	}
+	if !uc.checkPassword(c, "proposed_password", userChangePassword.ProposedPassword, user.Attributes["email"], user.Attributes["name"]) {
+		return
+	}
+
+	err = uc.idpAdapter.ChangePassword(c, token.(string), userChangePassword)
+	if err != nil {
+		respondError(c, uc.logger, err, "User change password failed")
+		return
+	}
+	uc.logger.Info("User change password successfully")
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary Log out
+// @Description Revoke a refresh token and the access token used for this request
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param body body entity.Logout true "Refresh token to revoke"
+// @Success 200
+// @Failure 400 {object} entity.Problem
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /logout [post]
+func (uc *UserController) Logout(c *gin.Context) {
+	var logout entity.Logout
+	if !bindJSON(c, &logout) {
 		return
 	}
 
-	err := uc.idpAdapter.ChangePassword(c, token.(string), userChangePassword)
+	err := uc.idpAdapter.RevokeToken(c, logout.RefreshToken)
 	if err != nil {
-		var customErr *utils.CustomError
-		if errors.As(err, &customErr) {
-			c.JSON(customErr.Status, gin.H{"error": customErr.Message})
-			uc.logger.Error("User change password failed", zap.String("error", customErr.Message))
-			return
+		respondError(c, uc.logger, err, "User logout failed")
+		return
+	}
+
+	claims, _ := c.Get("claims")
+	if claims, ok := claims.(jwt.MapClaims); ok {
+		jti, _ := claims["jti"].(string)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			uc.revocations.RevokeToken(jti, exp.Time)
 		}
-		uc.logger.Error("Failed change password", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	} else {
-		uc.logger.Info("User change password successfully")
 	}
+	uc.logger.Info("User logout successfully")
 
 	c.Status(http.StatusOK)
 }
 
+// @Summary Log out everywhere
+// @Description Sign the authenticated user out of all devices, invalidating every issued token
+// @Tags User
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Success 200
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /logout/global [post]
+func (uc *UserController) GlobalSignOut(c *gin.Context) {
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	err := uc.idpAdapter.GlobalSignOut(c, token.(string))
+	if err != nil {
+		respondError(c, uc.logger, err, "User global sign out failed")
+		return
+	}
+
+	if identity, ok := middleware.GetIdentity(c); ok {
+		uc.revocations.RevokeSubject(identity.Sub, time.Now())
+	}
+	uc.logger.Info("User global sign out successfully")
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary Start authenticator app enrollment
+// @Description Generate a TOTP secret for the authenticated user, returned with an otpauth:// URI for QR codes
+// @Tags MFA
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.SoftwareTokenAssociation}
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /mfa/totp/associate [post]
+func (uc *UserController) AssociateSoftwareToken(c *gin.Context) {
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	result, err := uc.idpAdapter.AssociateSoftwareToken(c, token.(string))
+	if err != nil {
+		respondError(c, uc.logger, err, "User associate software token failed")
+		return
+	}
+	result.URI = totp.KeyURI(uc.mfaIssuer, result.AccountName, result.SecretCode)
+	uc.logger.Info("User associate software token successfully")
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Summary Verify authenticator app
+// @Description Confirm TOTP enrollment with a code from the authenticator app
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param body body entity.VerifySoftwareToken true "Code from the authenticator app"
+// @Success 200
+// @Failure 400 {object} entity.Problem "Invalid Code"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /mfa/totp/verify [post]
+func (uc *UserController) VerifySoftwareToken(c *gin.Context) {
+	var verifySoftwareToken entity.VerifySoftwareToken
+	if !bindJSON(c, &verifySoftwareToken) {
+		return
+	}
+
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	err := uc.idpAdapter.VerifySoftwareToken(c, token.(string), verifySoftwareToken)
+	if err != nil {
+		respondError(c, uc.logger, err, "User verify software token failed")
+		return
+	}
+	uc.logger.Info("User verify software token successfully")
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary Set MFA preference
+// @Description Enable, disable or prefer TOTP and SMS multi-factor authentication for the authenticated user
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param body body entity.MFAPreference true "MFA settings, omitted factors are left unchanged"
+// @Success 200
+// @Failure 400 {object} entity.Problem "Invalid Parameter"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /mfa/preference [put]
+func (uc *UserController) SetMFAPreference(c *gin.Context) {
+	var mfaPreference entity.MFAPreference
+	if !bindJSON(c, &mfaPreference) {
+		return
+	}
+	auditMFASetting(c, "totp", mfaPreference.TOTP)
+	auditMFASetting(c, "sms", mfaPreference.SMS)
+
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	err := uc.idpAdapter.SetMFAPreference(c, token.(string), mfaPreference)
+	if err != nil {
+		respondError(c, uc.logger, err, "User set MFA preference failed")
+		return
+	}
+	uc.logger.Info("User set MFA preference successfully")
+
+	c.Status(http.StatusOK)
+}
+
+// auditMFASetting records a changed factor as e.g. totp=enabled,preferred.
+func auditMFASetting(c *gin.Context, factor string, setting *entity.MFASetting) {
+	if setting == nil {
+		return
+	}
+	value := "disabled"
+	if setting.Enabled {
+		value = "enabled"
+	}
+	if setting.Preferred {
+		value += ",preferred"
+	}
+	audit.SetDetail(c, factor, value)
+}
+
 // @Summary Change user password
 // @Description Change the password for the authenticated user
 // @Tags User
 // @Produce json
 // @Param Authorization header string true "Bearer {token}" default(Bearer <Add access token here>)
 // @Success 200 {object} entity.ResponseWrapper
-// @Failure 401 {object} entity.ErrorWrapper "Not Authorized"
-// @Failure 500 {object} entity.ErrorWrapper
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 500 {object} entity.Problem
 // @Router /sub [get]
 func GetSub(c *gin.Context) {
 	sub, exists := c.Get("sub")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Subject not found"})
+		c.Error(errors.New("subject not found"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": sub})
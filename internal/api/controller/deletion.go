@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/audit"
+	"github.com/Zeta-Manu/manu-auth/pkg/deletion"
+	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+var (
+	errIncorrectPassword = &utils.CustomError{
+		Message: "Incorrect password",
+		Status:  http.StatusForbidden,
+		Code:    utils.ErrCodeIncorrectPassword,
+	}
+	errChallengeUserMismatch = &utils.CustomError{
+		Message: "user_id does not belong to email",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeInvalidParameter,
+	}
+)
+
+// @Summary Delete own account
+// @Description Disable the account right away and delete it for good after the grace period. Logging in again before then cancels the deletion
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param body body entity.DeleteAccount true "Current password"
+// @Success 202 {object} entity.ResponseWrapper{data=entity.AccountDeletion}
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Incorrect password"
+// @Failure 429 {object} entity.Problem "Too many failed password attempts, see Retry-After"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /me [delete]
+func (uc *UserController) DeleteAccount(c *gin.Context) {
+	var deleteAccount entity.DeleteAccount
+	if !bindJSON(c, &deleteAccount) {
+		return
+	}
+
+	token, exists := c.Get("token")
+	if !exists {
+		c.Error(errUnauthorized)
+		return
+	}
+
+	user, err := uc.idpAdapter.GetUser(c, token.(string))
+	if err != nil {
+		respondError(c, uc.logger, err, "User delete account failed")
+		return
+	}
+	email := user.Attributes["email"]
+	audit.SetSubject(c, email)
+
+	// Re-checking the password is a login attempt like any other.
+	if !uc.checkLockout(c, email) {
+		return
+	}
+	if err := uc.verifyPassword(c, email, deleteAccount.Password); err != nil {
+		respondError(c, uc.logger, err, "User delete account failed")
+		return
+	}
+
+	deleteAt, err := uc.scheduleDeletion(c, token.(string), user)
+	if err != nil {
+		respondError(c, uc.logger, err, "User delete account failed")
+		return
+	}
+
+	if identity, ok := middleware.GetIdentity(c); ok {
+		uc.revocations.RevokeSubject(identity.Sub, time.Now())
+	}
+	uc.logger.Info("User account deletion scheduled", zap.Time("DeleteAt", deleteAt))
+
+	c.JSON(http.StatusAccepted, gin.H{"data": entity.AccountDeletion{DeleteAt: deleteAt}})
+}
+
+// verifyPassword reports errIncorrectPassword unless password is right, and
+// counts a wrong one against the lockout like a failed login. A challenge
+// such as MFA is only issued once the password was right. Any tokens issued
+// here are invalidated by the global sign-out that follows.
+func (uc *UserController) verifyPassword(c *gin.Context, email, password string) error {
+	_, err := uc.idpAdapter.Login(c, entity.UserLogin{Email: email, Password: password})
+	uc.recordLogin(c, email, err)
+
+	var customErr *utils.CustomError
+	if errors.As(err, &customErr) && customErr.Status == http.StatusUnauthorized {
+		return errIncorrectPassword
+	}
+	return err
+}
+
+// scheduleDeletion signs the user out everywhere, records the deletion and
+// disables the account, in that order, so a failure never leaves a disabled
+// account nobody will delete.
+func (uc *UserController) scheduleDeletion(c *gin.Context, accessToken string, user *entity.User) (time.Time, error) {
+	email := user.Attributes["email"]
+
+	if err := uc.idpAdapter.GlobalSignOut(c, accessToken); err != nil {
+		return time.Time{}, err
+	}
+
+	pending, err := uc.deletions.Schedule(email, user.Username)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := uc.idpAdapter.AdminDisableUser(c, user.Username); err != nil {
+		if cancelErr := uc.deletions.Cancel(email); cancelErr != nil {
+			uc.logger.Error("Failed to drop pending deletion", zap.Error(cancelErr))
+		}
+		return time.Time{}, err
+	}
+
+	return pending.DeleteAt, nil
+}
+
+// login is Login for accounts that may be waiting for deletion.
+func (uc *UserController) login(c *gin.Context, userLogin entity.UserLogin) (*entity.AuthResult, error) {
+	pending, ok := uc.pendingDeletion(userLogin.Email)
+	if !ok {
+		return uc.idpAdapter.Login(c, userLogin)
+	}
+	return uc.withPendingDeletion(c, pending, func() (*entity.AuthResult, error) {
+		return uc.idpAdapter.Login(c, userLogin)
+	})
+}
+
+// respondToChallenge is RespondToChallenge for accounts that may be waiting
+// for deletion, so answering MFA after the password cancels it too. The
+// identity provider signs in as user_id, so it has to be the account of the
+// email before that account is enabled.
+func (uc *UserController) respondToChallenge(c *gin.Context, challengeResponse entity.ChallengeResponse) (*entity.AuthResult, error) {
+	pending, ok := uc.pendingDeletion(challengeResponse.Email)
+	if !ok {
+		return uc.idpAdapter.RespondToChallenge(c, challengeResponse)
+	}
+	if challengeResponse.UserID != pending.Username {
+		return nil, errChallengeUserMismatch
+	}
+	return uc.withPendingDeletion(c, pending, func() (*entity.AuthResult, error) {
+		return uc.idpAdapter.RespondToChallenge(c, challengeResponse)
+	})
+}
+
+// pendingDeletion returns the deletion of email still in its grace period.
+// Past it the account stays disabled until the scheduler deletes it.
+func (uc *UserController) pendingDeletion(email string) (deletion.Pending, bool) {
+	pending, ok := uc.deletions.Lookup(email)
+	if !ok || !time.Now().Before(pending.DeleteAt) {
+		return deletion.Pending{}, false
+	}
+	return pending, true
+}
+
+// withPendingDeletion enables the disabled account of pending for one
+// authentication step. The deletion is only cancelled once the step issued
+// tokens, and only if they belong to that account; otherwise the account is
+// disabled again. Callers run behind the lockout tracker, which throttles how
+// often this can happen.
+func (uc *UserController) withPendingDeletion(c *gin.Context, pending deletion.Pending, authenticate func() (*entity.AuthResult, error)) (*entity.AuthResult, error) {
+	if err := uc.idpAdapter.AdminEnableUser(c, pending.Username); err != nil {
+		return nil, err
+	}
+
+	result, err := authenticate()
+	if err == nil && result.Challenge == nil {
+		var signedIn bool
+		signedIn, err = uc.signedInAs(c, result.Tokens, pending.Username)
+		if err == nil && signedIn {
+			if err := uc.deletions.Cancel(pending.Email); err != nil {
+				return nil, err
+			}
+			uc.logger.Info("User account deletion cancelled", zap.String("Email", pending.Email))
+			return result, nil
+		}
+	}
+
+	if disableErr := uc.idpAdapter.AdminDisableUser(c, pending.Username); disableErr != nil {
+		uc.logger.Error("Failed to disable account pending deletion", zap.String("Email", pending.Email), zap.Error(disableErr))
+		return nil, disableErr
+	}
+	return result, err
+}
+
+// signedInAs reports whether tokens were issued to username.
+func (uc *UserController) signedInAs(c *gin.Context, tokens *entity.LoginResult, username string) (bool, error) {
+	if tokens == nil || tokens.AccessToken == nil {
+		return false, nil
+	}
+	user, err := uc.idpAdapter.GetUser(c, *tokens.AccessToken)
+	if err != nil {
+		return false, err
+	}
+	return user.Username == username, nil
+}
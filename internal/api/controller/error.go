@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/Zeta-Manu/manu-auth/internal/api/validation"
+	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+// Handlers only record errors with c.Error; the problem middleware writes
+// the response.
+
+var errUnauthorized = &utils.CustomError{
+	Message: "Unauthorized",
+	Status:  http.StatusUnauthorized,
+	Code:    utils.ErrCodeNotAuthorized,
+}
+
+// bindJSON decodes the body into obj and checks its binding rules. When that
+// fails it records a 400 and the handler should return.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	if fields, ok := validation.FieldErrors(err); ok {
+		c.Error(&utils.CustomError{
+			Message: "Request validation failed",
+			Status:  http.StatusBadRequest,
+			Code:    utils.ErrCodeValidationFailed,
+			Fields:  fields,
+			Err:     err,
+		})
+		return false
+	}
+	c.Error(&utils.CustomError{
+		Message: "Invalid request body",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeInvalidRequest,
+		Err:     err,
+	})
+	return false
+}
+
+// invalidParameter records a 400 for a request that decoded but makes no sense.
+func invalidParameter(c *gin.Context, message string) {
+	c.Error(&utils.CustomError{
+		Message: message,
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeInvalidParameter,
+	})
+}
+
+// respondError logs err under message and records it for the response.
+func respondError(c *gin.Context, logger *zap.Logger, err error, message string) {
+	fields := []zap.Field{zap.String("request_id", middleware.GetRequestID(c))}
+	var customErr *utils.CustomError
+	if errors.As(err, &customErr) {
+		fields = append(fields, customErr.LogFields()...)
+	} else {
+		fields = append(fields, zap.Error(err))
+	}
+	logger.Error(message, fields...)
+	c.Error(err)
+}
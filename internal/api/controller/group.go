@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/audit"
+	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+)
+
+// @Summary Create group
+// @Description Create a group to hold a product role
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param body body entity.CreateGroup true "Group to create"
+// @Success 201 {object} entity.ResponseWrapper{data=entity.Group}
+// @Failure 400 {object} entity.Problem "Invalid Parameter"
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 409 {object} entity.Problem "Group already exists"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/groups [post]
+func (ac *AdminController) CreateGroup(c *gin.Context) {
+	var createGroup entity.CreateGroup
+	if !bindJSON(c, &createGroup) {
+		return
+	}
+	audit.SetSubject(c, createGroup.Name)
+
+	result, err := ac.idpAdapter.CreateGroup(c, createGroup)
+	if err != nil {
+		respondError(c, ac.logger, err, "Admin create group failed")
+		return
+	}
+	ac.logger.Info("Admin create group successfully", zap.String("Admin", adminName(c)), zap.String("Group", createGroup.Name))
+
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+// @Summary List groups
+// @Description List the groups of the user pool, a page at a time
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param limit query int false "Page size, at most 60"
+// @Param next_token query string false "Token from the previous page"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.GroupList}
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/groups [get]
+func (ac *AdminController) ListGroups(c *gin.Context) {
+	limit, ok := parseLimit(c, 60)
+	if !ok {
+		return
+	}
+
+	result, err := ac.idpAdapter.ListGroups(c, limit, c.Query("next_token"))
+	if err != nil {
+		respondError(c, ac.logger, err, "Admin list groups failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Summary List a user's groups
+// @Description List the groups the user belongs to
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Param limit query int false "Page size, at most 60"
+// @Param next_token query string false "Token from the previous page"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.GroupList}
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username}/groups [get]
+func (ac *AdminController) ListGroupsForUser(c *gin.Context) {
+	limit, ok := parseLimit(c, 60)
+	if !ok {
+		return
+	}
+
+	result, err := ac.idpAdapter.AdminListGroupsForUser(c, c.Param("username"), limit, c.Query("next_token"))
+	if err != nil {
+		respondError(c, ac.logger, err, "Admin list groups for user failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Summary Add user to group
+// @Description Add the user to the group. Tokens list the new group once they are refreshed
+// @Tags Admin
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Param group path string true "Group name"
+// @Success 200
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User or group not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username}/groups/{group} [put]
+func (ac *AdminController) AddUserToGroup(c *gin.Context) {
+	username, group := c.Param("username"), c.Param("group")
+	audit.SetSubject(c, username)
+	audit.SetDetail(c, "group", group)
+	if err := ac.idpAdapter.AdminAddUserToGroup(c, username, group); err != nil {
+		respondError(c, ac.logger, err, "Admin add user to group failed")
+		return
+	}
+	ac.logger.Info("Admin add user to group successfully", zap.String("Admin", adminName(c)), zap.String("Username", username), zap.String("Group", group))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary Remove user from group
+// @Description Remove the user from the group. Tokens stop listing it once they are refreshed
+// @Tags Admin
+// @Param Authorization header string true "Bearer {token}"
+// @Param username path string true "Username or email"
+// @Param group path string true "Group name"
+// @Success 200
+// @Failure 401 {object} entity.Problem "Not Authorized"
+// @Failure 403 {object} entity.Problem "Forbidden"
+// @Failure 404 {object} entity.Problem "User or group not found"
+// @Failure 500 {object} entity.Problem
+// @Security BearerAuth
+// @Router /admin/users/{username}/groups/{group} [delete]
+func (ac *AdminController) RemoveUserFromGroup(c *gin.Context) {
+	username, group := c.Param("username"), c.Param("group")
+	audit.SetSubject(c, username)
+	audit.SetDetail(c, "group", group)
+	if err := ac.idpAdapter.AdminRemoveUserFromGroup(c, username, group); err != nil {
+		respondError(c, ac.logger, err, "Admin remove user from group failed")
+		return
+	}
+	ac.logger.Info("Admin remove user from group successfully", zap.String("Admin", adminName(c)), zap.String("Username", username), zap.String("Group", group))
+
+	c.Status(http.StatusOK)
+}
+
+// adminName identifies the caller in logs of admin actions.
+func adminName(c *gin.Context) string {
+	if identity, ok := middleware.GetIdentity(c); ok {
+		return identity.Username
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+// checkPassword records a 400 listing every local password rule the proposed
+// password breaks, so weak passwords never reach the identity provider. A
+// nil checker accepts everything.
+func (uc *UserController) checkPassword(c *gin.Context, field, password, email, name string) bool {
+	if uc.passwords == nil {
+		return true
+	}
+	result := uc.passwords.Check(password, email, name)
+	if result.Acceptable() {
+		return true
+	}
+
+	fields := make([]utils.FieldError, 0, len(result.Violations))
+	for _, violation := range result.Violations {
+		fields = append(fields, utils.FieldError{Field: field, Rule: violation.Rule, Message: violation.Message})
+	}
+	c.Error(&utils.CustomError{
+		Message: "Password rejected by policy",
+		Status:  http.StatusBadRequest,
+		Code:    utils.ErrCodeValidationFailed,
+		Fields:  fields,
+	})
+	return false
+}
+
+// @Summary Check password strength
+// @Description Score a password and list the rules it breaks, for live feedback on forms. Nothing is stored.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param body body entity.PasswordStrength true "Password, and optionally the email and name it belongs to"
+// @Success 200 {object} entity.ResponseWrapper{data=entity.PasswordStrengthResult}
+// @Failure 400 {object} entity.Problem
+// @Router /password/strength [post]
+func (uc *UserController) PasswordStrength(c *gin.Context) {
+	var passwordStrength entity.PasswordStrength
+	if !bindJSON(c, &passwordStrength) {
+		return
+	}
+
+	response := entity.PasswordStrengthResult{Acceptable: true, Violations: []entity.PasswordViolation{}}
+	if uc.passwords != nil {
+		result := uc.passwords.Check(passwordStrength.Password, passwordStrength.Email, passwordStrength.Name)
+		response.Score = result.Score
+		response.Acceptable = result.Acceptable()
+		for _, violation := range result.Violations {
+			response.Violations = append(response.Violations, entity.PasswordViolation{Rule: violation.Rule, Message: violation.Message})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+var (
+	DefaultReadableAttributes = []string{"email", "email_verified", "name", "phone_number", "phone_number_verified"}
+	DefaultWritableAttributes = []string{"name"}
+)
+
+// ProfileAttributes is the allowlist of user attributes /me reads and writes.
+// sub and the username are always returned.
+type ProfileAttributes struct {
+	readable map[string]bool
+	writable map[string]bool
+}
+
+// NewProfileAttributes builds the allowlist; an empty list falls back to its default.
+func NewProfileAttributes(readable, writable []string) ProfileAttributes {
+	if len(readable) == 0 {
+		readable = DefaultReadableAttributes
+	}
+	if len(writable) == 0 {
+		writable = DefaultWritableAttributes
+	}
+
+	p := ProfileAttributes{
+		readable: make(map[string]bool, len(readable)),
+		writable: make(map[string]bool, len(writable)),
+	}
+	for _, name := range readable {
+		p.readable[name] = true
+	}
+	for _, name := range writable {
+		p.writable[name] = true
+	}
+	return p
+}
+
+func (p ProfileAttributes) profile(user *entity.User) *entity.UserProfile {
+	profile := &entity.UserProfile{
+		Sub:      user.Attributes["sub"],
+		Username: user.Username,
+	}
+
+	for name, value := range user.Attributes {
+		if !p.readable[name] {
+			continue
+		}
+		switch name {
+		case "sub":
+		case "email":
+			profile.Email = value
+		case "email_verified":
+			verified := value == "true"
+			profile.EmailVerified = &verified
+		case "name":
+			profile.Name = value
+		case "phone_number":
+			profile.PhoneNumber = value
+		case "phone_number_verified":
+			verified := value == "true"
+			profile.PhoneNumberVerified = &verified
+		default:
+			if profile.Attributes == nil {
+				profile.Attributes = make(map[string]string)
+			}
+			profile.Attributes[name] = value
+		}
+	}
+
+	return profile
+}
+
+func (p ProfileAttributes) checkWritable(attributes map[string]string) error {
+	if len(attributes) == 0 {
+		return &utils.CustomError{
+			Message: "No attributes to update",
+			Status:  http.StatusBadRequest,
+			Code:    utils.ErrCodeNoAttributes,
+		}
+	}
+	for name := range attributes {
+		if !p.writable[name] {
+			return &utils.CustomError{
+				Message: fmt.Sprintf("Attribute %s is not writable", name),
+				Status:  http.StatusBadRequest,
+				Code:    utils.ErrCodeAttributeNotWritable,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Zeta-Manu/manu-auth/internal/domain/entity"
+	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+const ContentType = "application/problem+json"
+
+var errInternal = &utils.CustomError{
+	Message: "Internal error",
+	Status:  http.StatusInternalServerError,
+	Code:    utils.ErrCodeInternal,
+}
+
+// Middleware renders the last error handlers recorded with c.Error. Handlers
+// never write error bodies themselves, so every error has the same shape.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		problem := New(c, c.Errors.Last().Err)
+
+		body, err := json.Marshal(problem)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Data(problem.Status, ContentType, body)
+	}
+}
+
+// Recover is the gin.RecoveryFunc for panics below Middleware: it records an
+// internal error instead of writing a bare 500, so Middleware renders it.
+func Recover(c *gin.Context, recovered interface{}) {
+	c.Error(errInternal)
+	c.Abort()
+}
+
+// New describes err as a problem. Anything but a utils.CustomError is an
+// internal error whose text is only fit for the logs.
+func New(c *gin.Context, err error) entity.Problem {
+	var customErr *utils.CustomError
+	if !errors.As(err, &customErr) || customErr.Status == 0 {
+		customErr = errInternal
+	}
+
+	code := customErr.Code
+	if code == "" {
+		code = utils.ErrCodeInternal
+	}
+
+	return entity.Problem{
+		Type:      "/problems/" + strings.ToLower(strings.ReplaceAll(code, "_", "-")),
+		Title:     http.StatusText(customErr.Status),
+		Status:    customErr.Status,
+		Detail:    customErr.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetRequestID(c),
+		Errors:    customErr.Fields,
+	}
+}
@@ -2,24 +2,86 @@ package route
 
 import (
 	"github.com/Zeta-Manu/manu-auth/internal/adapter/idp"
+	"github.com/Zeta-Manu/manu-auth/internal/adapter/oauth"
 	"github.com/Zeta-Manu/manu-auth/internal/api/controller"
+	"github.com/Zeta-Manu/manu-auth/pkg/audit"
+	"github.com/Zeta-Manu/manu-auth/pkg/deletion"
+	"github.com/Zeta-Manu/manu-auth/pkg/lockout"
 	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+	"github.com/Zeta-Manu/manu-auth/pkg/password"
+	"github.com/Zeta-Manu/manu-auth/pkg/ratelimit"
+	"github.com/Zeta-Manu/manu-auth/pkg/revocation"
 	"github.com/Zeta-Manu/manu-auth/pkg/utils"
 )
 
-func InitRoutes(router utils.RouterWithLogger, idpAdapter idp.CognitoAdapter, jwtPublicKey string) {
-	userController := controller.NewUserController(idpAdapter, router.Logger)
+// RateLimits holds the rules for the unauthenticated endpoints that send mail
+// or hit the identity provider.
+type RateLimits struct {
+	Login          ratelimit.Rule
+	Signup         ratelimit.Rule
+	ForgotPassword ratelimit.Rule
+	ResendConfirm  ratelimit.Rule
+	Challenge      ratelimit.Rule
+	Refresh        ratelimit.Rule
+	ConfirmForgot  ratelimit.Rule
+	// EmailChange is shared by /me/email and /me/email/resend, which both
+	// send a code.
+	EmailChange ratelimit.Rule
+}
+
+func InitRoutes(router utils.RouterWithLogger, idpAdapter idp.IdentityProvider, validator *middleware.TokenValidator, revocations *revocation.List, deletions *deletion.Scheduler, mfaIssuer string, profile controller.ProfileAttributes, hostedUI *oauth.HostedUI, adminGroup string, limiter *ratelimit.Limiter, limits RateLimits, lockouts *lockout.Tracker, passwords *password.Checker, trail *audit.Trail) {
+	userController := controller.NewUserController(idpAdapter, revocations, deletions, lockouts, passwords, mfaIssuer, profile, router.Logger)
 	//
 	user := router.Router.Group("/api/v2")
 	{
-		user.POST("/signup", userController.SignUp)
-		user.POST("/confirm", userController.ConfirmSignUp)
-		user.POST("/resend-confirm", userController.ResendConfirmationCode)
-		user.POST("/login", userController.LogIn)
-		user.POST("/forgot-password", userController.ForgotPassword)
-		user.POST("/confirm-forgot", userController.ConfirmForgotPassword)
+		user.POST("/signup", trail.Middleware(audit.TypeSignUp), limiter.Middleware("signup", limits.Signup), userController.SignUp)
+		user.POST("/confirm", trail.Middleware(audit.TypeConfirmSignUp), userController.ConfirmSignUp)
+		user.POST("/resend-confirm", limiter.Middleware("resend-confirm", limits.ResendConfirm), userController.ResendConfirmationCode)
+		user.POST("/login", trail.Middleware(audit.TypeLogin), limiter.Middleware("login", limits.Login), userController.LogIn)
+		user.POST("/challenge", trail.Middleware(audit.TypeChallenge), limiter.Middleware("challenge", limits.Challenge), userController.RespondToChallenge)
+		user.POST("/refresh", limiter.Middleware("refresh", limits.Refresh), userController.RefreshToken)
+		user.POST("/forgot-password", trail.Middleware(audit.TypeForgotPassword), limiter.Middleware("forgot-password", limits.ForgotPassword), userController.ForgotPassword)
+		user.POST("/confirm-forgot", trail.Middleware(audit.TypeConfirmForgot), limiter.Middleware("confirm-forgot", limits.ConfirmForgot), userController.ConfirmForgotPassword)
+		user.POST("/password/strength", userController.PasswordStrength)
 		// route with middleware
-		user.POST("/password", middleware.AuthenticationMiddleware(jwtPublicKey), userController.ChangePassword)
-		user.GET("/sub", middleware.AuthenticationMiddleware(jwtPublicKey), controller.GetSub)
+		user.POST("/password", trail.Middleware(audit.TypeChangePassword), middleware.AuthenticationMiddleware(validator), userController.ChangePassword)
+		user.GET("/sub", middleware.AuthenticationMiddleware(validator), controller.GetSub)
+		user.GET("/me", middleware.AuthenticationMiddleware(validator), userController.GetProfile)
+		user.PATCH("/me", middleware.AuthenticationMiddleware(validator), userController.UpdateProfile)
+		user.DELETE("/me", trail.Middleware(audit.TypeDeleteAccount), middleware.AuthenticationMiddleware(validator), userController.DeleteAccount)
+		user.POST("/me/email", trail.Middleware(audit.TypeChangeEmail), limiter.Middleware("email-change", limits.EmailChange), middleware.AuthenticationMiddleware(validator), userController.ChangeEmail)
+		user.POST("/me/email/verify", trail.Middleware(audit.TypeVerifyEmail), middleware.AuthenticationMiddleware(validator), userController.VerifyEmail)
+		user.POST("/me/email/resend", trail.Middleware(audit.TypeResendEmailCode), limiter.Middleware("email-change", limits.EmailChange), middleware.AuthenticationMiddleware(validator), userController.ResendEmailVerification)
+		user.POST("/logout", trail.Middleware(audit.TypeLogout), middleware.AuthenticationMiddleware(validator), userController.Logout)
+		user.POST("/logout/global", trail.Middleware(audit.TypeGlobalSignOut), middleware.AuthenticationMiddleware(validator), userController.GlobalSignOut)
+		user.POST("/mfa/totp/associate", trail.Middleware(audit.TypeMFAAssociateTOTP), middleware.AuthenticationMiddleware(validator), userController.AssociateSoftwareToken)
+		user.POST("/mfa/totp/verify", trail.Middleware(audit.TypeMFAVerifyTOTP), middleware.AuthenticationMiddleware(validator), userController.VerifySoftwareToken)
+		user.PUT("/mfa/preference", trail.Middleware(audit.TypeMFASetPreference), middleware.AuthenticationMiddleware(validator), userController.SetMFAPreference)
+	}
+
+	adminController := controller.NewAdminController(idpAdapter, lockouts, router.Logger)
+	admin := user.Group("/admin", middleware.AuthenticationMiddleware(validator), middleware.RequireGroups(middleware.MatchAny, adminGroup))
+	{
+		admin.GET("/users", adminController.ListUsers)
+		admin.GET("/users/:username", adminController.GetUser)
+		admin.POST("/users/:username/disable", trail.Middleware(audit.TypeAdminDisableUser), adminController.DisableUser)
+		admin.POST("/users/:username/enable", trail.Middleware(audit.TypeAdminEnableUser), adminController.EnableUser)
+		admin.POST("/users/:username/reset-password", trail.Middleware(audit.TypeAdminResetPassword), adminController.ResetUserPassword)
+		admin.POST("/users/:username/resend-invitation", trail.Middleware(audit.TypeAdminResendInvite), adminController.ResendInvitation)
+		admin.DELETE("/users/:username", trail.Middleware(audit.TypeAdminDeleteUser), adminController.DeleteUser)
+		admin.GET("/users/:username/groups", adminController.ListGroupsForUser)
+		admin.PUT("/users/:username/groups/:group", trail.Middleware(audit.TypeAdminAddToGroup), adminController.AddUserToGroup)
+		admin.DELETE("/users/:username/groups/:group", trail.Middleware(audit.TypeAdminRemoveFromGroup), adminController.RemoveUserFromGroup)
+		admin.GET("/groups", adminController.ListGroups)
+		admin.POST("/groups", trail.Middleware(audit.TypeAdminCreateGroup), adminController.CreateGroup)
+		admin.GET("/lockouts", adminController.ListLockouts)
+		admin.DELETE("/lockouts/:kind/:key", trail.Middleware(audit.TypeAdminClearLockout), adminController.ClearLockout)
+	}
+
+	// The hosted UI endpoints only exist when a user pool domain is configured.
+	if hostedUI != nil {
+		oauthController := controller.NewOAuthController(hostedUI, router.Logger)
+		user.GET("/oauth/authorize", oauthController.Authorize)
+		user.GET("/oauth/callback", trail.Middleware(audit.TypeOAuthLogin), oauthController.Callback)
 	}
 }
@@ -0,0 +1,101 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/Zeta-Manu/manu-auth/internal/adapter/idp"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+// policy backs the "password" rule. It is set once by Register, before the
+// server accepts requests.
+var policy = idp.DefaultPasswordPolicy()
+
+// Register teaches gin's validator the rules the entities use beyond the
+// built-in ones. passwordPolicy should mirror the user pool's, so weak
+// passwords are turned down before reaching the identity provider.
+func Register(passwordPolicy idp.PasswordPolicy) error {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+
+	policy = passwordPolicy
+	// Report fields by the names clients send.
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return engine.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return policy.Violation(fl.Field().String()) == ""
+	})
+}
+
+// FieldErrors lists the rules err reports as broken, or returns false when
+// err is not a validation failure, e.g. malformed JSON.
+func FieldErrors(err error) ([]utils.FieldError, bool) {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+
+	fields := make([]utils.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, utils.FieldError{
+			Field:   fieldName(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: message(fieldErr),
+		})
+	}
+	return fields, true
+}
+
+// fieldName drops the struct name from the namespace, e.g.
+// UserRegistration.password becomes password.
+func fieldName(fieldErr validator.FieldError) string {
+	_, name, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+	return name
+}
+
+func message(fieldErr validator.FieldError) string {
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Map, reflect.Slice:
+		unit = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "numeric":
+		return "must contain only digits"
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", fieldErr.Param(), unit)
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", fieldErr.Param(), unit)
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", fieldErr.Param(), unit)
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "password":
+		return policy.Violation(fmt.Sprint(fieldErr.Value()))
+	default:
+		return "is invalid"
+	}
+}
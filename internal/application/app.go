@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -14,35 +15,149 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 
 	"github.com/Zeta-Manu/manu-auth/config"
 	docs "github.com/Zeta-Manu/manu-auth/docs"
 	"github.com/Zeta-Manu/manu-auth/internal/adapter/idp"
+	"github.com/Zeta-Manu/manu-auth/internal/adapter/oauth"
+	"github.com/Zeta-Manu/manu-auth/internal/api/controller"
+	"github.com/Zeta-Manu/manu-auth/internal/api/problem"
 	"github.com/Zeta-Manu/manu-auth/internal/api/route"
+	"github.com/Zeta-Manu/manu-auth/internal/api/validation"
+	"github.com/Zeta-Manu/manu-auth/pkg/audit"
+	"github.com/Zeta-Manu/manu-auth/pkg/deletion"
+	"github.com/Zeta-Manu/manu-auth/pkg/jwks"
+	"github.com/Zeta-Manu/manu-auth/pkg/lockout"
+	"github.com/Zeta-Manu/manu-auth/pkg/metrics"
+	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+	"github.com/Zeta-Manu/manu-auth/pkg/password"
+	"github.com/Zeta-Manu/manu-auth/pkg/ratelimit"
+	"github.com/Zeta-Manu/manu-auth/pkg/revocation"
+	"github.com/Zeta-Manu/manu-auth/pkg/tracing"
 	"github.com/Zeta-Manu/manu-auth/pkg/utils"
 )
 
 func NewApplication(cfg config.Config) {
-	idpAdapter, err := idp.NewCognitoAdapter(cfg.AuthService.AWS.AccessKey, cfg.AuthService.AWS.SecretAccessKey, cfg.AuthService.Cognito.UserPoolId, cfg.AuthService.Cognito.ClientId, cfg.AuthService.Cognito.Region)
+	logger, _ := zap.NewProduction()
+
+	idpAdapter, err := newIdentityProvider(cfg, logger)
 	if err != nil {
 		panic("failed")
 	}
 
+	if err := validation.Register(passwordPolicy(cfg)); err != nil {
+		logger.Fatal("Failed to register request validation", zap.Error(err))
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.AuthService.Tracing.Exporter,
+		Endpoint:    cfg.AuthService.Tracing.Endpoint,
+		ServiceName: cfg.AuthService.Tracing.ServiceName,
+		SampleRatio: cfg.AuthService.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	router := gin.Default()
+	// Client IPs key rate limits and lockouts, so only configured proxies
+	// may set X-Forwarded-For.
+	if err := router.SetTrustedProxies(cfg.AuthService.HTTP.TrustedProxies); err != nil {
+		logger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+	// Handlers pass the gin context to the identity provider; falling back to
+	// the request context carries the request span into Cognito calls.
+	router.ContextWithFallback = true
 
-	logger, _ := zap.NewProduction()
+	if cfg.AuthService.Tracing.Exporter != "" {
+		router.Use(otelgin.Middleware(cfg.AuthService.Tracing.ServiceName))
+	}
+	router.Use(middleware.RequestID())
+	if cfg.AuthService.Metrics.Enabled {
+		router.Use(metrics.Middleware())
+	}
 	router.Use(ginzap.Ginzap(logger, time.RFC3339, true))
-	router.Use(ginzap.RecoveryWithZap(logger, true))
+	// Recovery sits below the problem middleware so a panic is answered with
+	// a problem body like any other internal error.
+	router.Use(problem.Middleware())
+	router.Use(ginzap.CustomRecoveryWithZap(logger, true, problem.Recover))
 
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
 	router.Use(cors.New(corsConfig))
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(&utils.CustomError{Message: "Not found", Status: http.StatusNotFound, Code: utils.ErrCodeNotFound})
+	})
 
 	router.GET("/healthz", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "healthy"})
 	})
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	revocations := revocation.NewList(revocation.DefaultMaxTokenLifetime)
+	revocations.Start(ctx, time.Minute)
+
+	deletions, err := deletion.NewScheduler(cfg.AuthService.Deletion.StateFile, cfg.AuthService.Deletion.GracePeriod, func(ctx context.Context, username string) error {
+		err := idpAdapter.AdminDeleteUser(ctx, username)
+		// Someone already removed the user, e.g. from the console.
+		var customErr *utils.CustomError
+		if errors.As(err, &customErr) && customErr.Status == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}, logger)
+	if err != nil {
+		logger.Fatal("Failed to load pending account deletions", zap.Error(err))
+	}
+	deletions.Start(ctx, time.Minute)
+
+	validation := middleware.ValidationOptions{
+		Issuer:      fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", cfg.AuthService.Cognito.Region, cfg.AuthService.Cognito.UserPoolId),
+		Audience:    []string{cfg.AuthService.Cognito.ClientId},
+		TokenUse:    cfg.AuthService.JWT.TokenUse,
+		Leeway:      cfg.AuthService.JWT.Leeway,
+		Revocations: revocations,
+	}
+
+	var keySource jwks.KeySource
+	memoryAdapter, isMemory := idpAdapter.(*idp.MemoryAdapter)
+	if isMemory {
+		validation.Issuer = memoryAdapter.Issuer()
+		validation.Audience = []string{memoryAdapter.ClientID()}
+	}
+	if isMemory && cfg.AuthService.JWT.PublicKey == "" {
+		router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+			c.JSON(http.StatusOK, memoryAdapter.KeySet())
+		})
+		keySource = jwks.NewStatic(memoryAdapter.KeySet())
+	} else {
+		keyCache := jwks.NewCache(cfg.AuthService.JWT.PublicKey, jwks.Options{
+			RefreshInterval:    cfg.AuthService.JWT.RefreshInterval,
+			MinRefreshInterval: cfg.AuthService.JWT.MinRefreshInterval,
+			CacheFile:          cfg.AuthService.JWT.CacheFile,
+		}, logger)
+		keyCache.Start(ctx)
+		keySource = keyCache
+	}
+
+	if cfg.AuthService.JWT.Issuer != "" {
+		validation.Issuer = cfg.AuthService.JWT.Issuer
+	}
+	if len(cfg.AuthService.JWT.Audience) > 0 {
+		validation.Audience = cfg.AuthService.JWT.Audience
+	}
+
 	r := utils.RouterWithLogger{
 		Router: router,
 		Logger: logger,
@@ -50,12 +165,219 @@ func NewApplication(cfg config.Config) {
 
 	docs.SwaggerInfo.BasePath = "/api/v2"
 
-	route.InitRoutes(r, *idpAdapter, cfg.AuthService.JWT.PublicKey)
+	var hostedUI *oauth.HostedUI
+	if cfg.AuthService.OAuth.Domain != "" {
+		if cfg.AuthService.OAuth.CookieSecret == "" {
+			logger.Warn("No OAuth cookie secret set, hosted UI logins must start and finish on the same replica")
+		}
+		hostedUI, err = oauth.NewHostedUI(oauth.Config{
+			Domain:       cfg.AuthService.OAuth.Domain,
+			ClientID:     cfg.AuthService.Cognito.ClientId,
+			ClientSecret: cfg.AuthService.Cognito.ClientSecret,
+			RedirectURIs: cfg.AuthService.OAuth.RedirectURIs,
+			Scopes:       cfg.AuthService.OAuth.Scopes,
+			StateTTL:     cfg.AuthService.OAuth.StateTTL,
+			CookieSecret: cfg.AuthService.OAuth.CookieSecret,
+		})
+		if err != nil {
+			logger.Fatal("Failed to set up the hosted UI", zap.Error(err))
+		}
+	}
+
+	limiter, err := newRateLimiter(ctx, cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to set up rate limiting", zap.Error(err))
+	}
+	rateLimits := route.RateLimits{
+		Login:          toRateLimitRule(cfg.AuthService.RateLimit.Login),
+		Signup:         toRateLimitRule(cfg.AuthService.RateLimit.Signup),
+		ForgotPassword: toRateLimitRule(cfg.AuthService.RateLimit.ForgotPassword),
+		ResendConfirm:  toRateLimitRule(cfg.AuthService.RateLimit.ResendConfirm),
+		Challenge:      toRateLimitRule(cfg.AuthService.RateLimit.Challenge),
+		Refresh:        toRateLimitRule(cfg.AuthService.RateLimit.Refresh),
+		ConfirmForgot:  toRateLimitRule(cfg.AuthService.RateLimit.ConfirmForgot),
+		EmailChange:    toRateLimitRule(cfg.AuthService.RateLimit.EmailChange),
+	}
+
+	passwords, err := newPasswordChecker(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to load breached password list", zap.Error(err))
+	}
+
+	trail, err := newAuditTrail(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to set up the audit trail", zap.Error(err))
+	}
+	if trail != nil {
+		defer trail.Close()
+	}
+
+	route.InitRoutes(r, idpAdapter, middleware.NewTokenValidator(keySource, validation), revocations, deletions, cfg.AuthService.MFA.Issuer,
+		controller.NewProfileAttributes(cfg.AuthService.Profile.ReadableAttributes, cfg.AuthService.Profile.WritableAttributes), hostedUI, cfg.AuthService.Admin.Group,
+		limiter, rateLimits, newLockoutTracker(ctx, cfg, logger), passwords, trail)
 	r.Router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
+	if cfg.AuthService.Metrics.Enabled {
+		startMetricsServer(cfg.AuthService.Metrics.Address, logger)
+	}
 	startServer(cfg, router, logger)
 }
 
+func newIdentityProvider(cfg config.Config, logger *zap.Logger) (idp.IdentityProvider, error) {
+	switch cfg.AuthService.IDP.Provider {
+	case "", "cognito":
+		return idp.NewCognitoAdapter(cfg.AuthService.AWS.AccessKey, cfg.AuthService.AWS.SecretAccessKey, cfg.AuthService.Cognito.UserPoolId, cfg.AuthService.Cognito.ClientId, cfg.AuthService.Cognito.ClientSecret, cfg.AuthService.Cognito.AuthFlow, cfg.AuthService.Cognito.Region, logger)
+	case "memory":
+		bootstrapGroups := make(map[string][]string, len(cfg.AuthService.IDP.MemoryAdmins))
+		for _, email := range cfg.AuthService.IDP.MemoryAdmins {
+			bootstrapGroups[email] = []string{cfg.AuthService.Admin.Group}
+		}
+		logger.Warn("Using in-memory identity provider, users will not survive a restart")
+		adapter, err := idp.NewMemoryAdapter(cfg.AuthService.Cognito.ClientId, passwordPolicy(cfg), bootstrapGroups, logger)
+		if err != nil {
+			return nil, err
+		}
+		for _, email := range cfg.AuthService.IDP.MemoryInvites {
+			if err := adapter.AdminCreateUser(context.Background(), email); err != nil {
+				return nil, fmt.Errorf("invite %s: %w", email, err)
+			}
+		}
+		return adapter, nil
+	default:
+		return nil, fmt.Errorf("unknown identity provider %q", cfg.AuthService.IDP.Provider)
+	}
+}
+
+func passwordPolicy(cfg config.Config) idp.PasswordPolicy {
+	return idp.PasswordPolicy{
+		MinimumLength:    cfg.AuthService.PasswordPolicy.MinimumLength,
+		RequireLowercase: cfg.AuthService.PasswordPolicy.RequireLowercase,
+		RequireUppercase: cfg.AuthService.PasswordPolicy.RequireUppercase,
+		RequireNumbers:   cfg.AuthService.PasswordPolicy.RequireNumbers,
+		RequireSymbols:   cfg.AuthService.PasswordPolicy.RequireSymbols,
+	}
+}
+
+func newPasswordChecker(cfg config.Config, logger *zap.Logger) (*password.Checker, error) {
+	var breached *password.BreachedList
+	if path := cfg.AuthService.PasswordPolicy.BreachedList; path != "" {
+		var err error
+		breached, err = password.LoadBreachedList(path)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Loaded breached password list", zap.String("Path", path), zap.Int("Entries", breached.Len()))
+	}
+
+	return password.NewChecker(password.Policy{
+		MinimumLength:    cfg.AuthService.PasswordPolicy.MinimumLength,
+		RequireLowercase: cfg.AuthService.PasswordPolicy.RequireLowercase,
+		RequireUppercase: cfg.AuthService.PasswordPolicy.RequireUppercase,
+		RequireNumbers:   cfg.AuthService.PasswordPolicy.RequireNumbers,
+		RequireSymbols:   cfg.AuthService.PasswordPolicy.RequireSymbols,
+		MinScore:         cfg.AuthService.PasswordPolicy.MinScore,
+	}, breached), nil
+}
+
+// newRateLimiter returns nil when rate limiting is disabled.
+func newRateLimiter(ctx context.Context, cfg config.Config, logger *zap.Logger) (*ratelimit.Limiter, error) {
+	if !cfg.AuthService.RateLimit.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.AuthService.RateLimit.Store {
+	case "", "memory":
+		store := ratelimit.NewMemoryStore()
+		store.Start(ctx, time.Minute)
+		return ratelimit.NewLimiter(store, logger), nil
+	case "redis":
+		store, err := ratelimit.NewRedisStore(cfg.AuthService.RateLimit.RedisURL, "manu-auth:ratelimit:")
+		if err != nil {
+			return nil, err
+		}
+		return ratelimit.NewLimiter(store, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.AuthService.RateLimit.Store)
+	}
+}
+
+func toRateLimitRule(cfg config.RateLimitRoute) ratelimit.Rule {
+	return ratelimit.Rule{
+		PerIP:         ratelimit.Limit(cfg.PerIP),
+		PerIdentifier: ratelimit.Limit(cfg.PerIdentifier),
+	}
+}
+
+// newLockoutTracker returns nil when lockouts are disabled.
+func newLockoutTracker(ctx context.Context, cfg config.Config, logger *zap.Logger) *lockout.Tracker {
+	if !cfg.AuthService.Lockout.Enabled {
+		return nil
+	}
+
+	notify := func(ctx context.Context, email string, entry lockout.Entry) error {
+		logger.Warn("Account owner should be told about failed logins", zap.String("Email", email), zap.Int("Failures", entry.Failures))
+		return nil
+	}
+	if cfg.AuthService.Lockout.NotifyWebhook != "" {
+		notify = lockout.WebhookNotifier(cfg.AuthService.Lockout.NotifyWebhook, &http.Client{Timeout: 10 * time.Second})
+	}
+
+	tracker := lockout.NewTracker(lockout.Policy(cfg.AuthService.Lockout.PerEmail), lockout.Policy(cfg.AuthService.Lockout.PerIP),
+		cfg.AuthService.Lockout.NotifyAfter, notify, logger)
+	tracker.Start(ctx, time.Minute)
+	return tracker
+}
+
+// newAuditTrail returns nil when auditing is disabled. The chain continues
+// from the last event in the audit file, and checkpoints go to stdout and the
+// webhook.
+func newAuditTrail(cfg config.Config, logger *zap.Logger) (*audit.Trail, error) {
+	if !cfg.AuthService.Audit.Enabled {
+		return nil, nil
+	}
+
+	var last audit.Event
+	var sinks []audit.Sink
+	if path := cfg.AuthService.Audit.File; path != "" {
+		var err error
+		last, err = audit.LastEvent(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := audit.NewFileSink(path, int64(cfg.AuthService.Audit.MaxSizeMB)<<20, cfg.AuthService.Audit.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, file)
+	}
+	if cfg.AuthService.Audit.Stdout {
+		sinks = append(sinks, audit.NewStreamSink(os.Stdout))
+	}
+	if cfg.AuthService.Audit.Webhook != "" {
+		sinks = append(sinks, audit.NewWebhookSink(cfg.AuthService.Audit.Webhook, &http.Client{Timeout: 10 * time.Second}, cfg.AuthService.Audit.WebhookBuffer, logger))
+	}
+
+	if cfg.AuthService.Audit.Secret == "" {
+		logger.Warn("No audit secret set, anyone able to edit the audit file can recompute its hash chain")
+	}
+	return audit.NewTrail(last, []byte(cfg.AuthService.Audit.Secret), cfg.AuthService.Audit.CheckpointInterval, logger, sinks...), nil
+}
+
+// startMetricsServer serves /metrics on its own listener so it is never
+// exposed with the API.
+func startMetricsServer(address string, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	srv := &http.Server{Addr: address, Handler: mux}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to Start Metrics Server", zap.Error(err))
+		}
+	}()
+	logger.Info("Serving metrics", zap.String("Address", address))
+}
+
 func startServer(cfg config.Config, handler http.Handler, logger *zap.Logger) {
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%v", cfg.AuthService.HTTP.Port),
@@ -0,0 +1,54 @@
+package entity
+
+import "time"
+
+const (
+	UserStatusUnconfirmed         = "UNCONFIRMED"
+	UserStatusConfirmed           = "CONFIRMED"
+	UserStatusResetRequired       = "RESET_REQUIRED"
+	UserStatusForceChangePassword = "FORCE_CHANGE_PASSWORD"
+)
+
+// UserFilter narrows ListUsers to users whose attribute equals, or with
+// Prefix starts with, Value. The zero value lists everyone.
+type UserFilter struct {
+	Attribute string
+	Value     string
+	Prefix    bool
+}
+
+type AdminUser struct {
+	Username   string            `json:"username"`
+	Enabled    bool              `json:"enabled"`
+	Status     string            `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+type UserList struct {
+	Users []AdminUser `json:"users"`
+	// PaginationToken fetches the next page; empty on the last one.
+	PaginationToken string `json:"pagination_token,omitempty"`
+}
+
+type Group struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Precedence  *int32    `json:"precedence,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type GroupList struct {
+	Groups []Group `json:"groups"`
+	// NextToken fetches the next page; empty on the last one.
+	NextToken string `json:"next_token,omitempty"`
+}
+
+type CreateGroup struct {
+	Name        string `json:"name" binding:"required,max=128"`
+	Description string `json:"description,omitempty" binding:"max=2048"`
+	// Precedence picks the group whose role wins when a user is in several; lower wins.
+	Precedence *int32 `json:"precedence,omitempty" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+const (
+	ChallengeNewPasswordRequired = "NEW_PASSWORD_REQUIRED"
+	ChallengeSMSMFA              = "SMS_MFA"
+	ChallengeSoftwareTokenMFA    = "SOFTWARE_TOKEN_MFA"
+	ChallengeSelectMFAType       = "SELECT_MFA_TYPE"
+)
+
+// AuthResult is the outcome of a login step: either tokens, or another
+// challenge the user has to answer first.
+type AuthResult struct {
+	Tokens    *LoginResult
+	Challenge *AuthChallenge
+}
+
+type AuthChallenge struct {
+	ChallengeName string `json:"challenge_name"`
+	Session       string `json:"session"`
+	// UserID is the username the email was resolved to, USER_ID_FOR_SRP.
+	// Answers must send it back as user_id.
+	UserID              string            `json:"user_id,omitempty"`
+	ChallengeParameters map[string]string `json:"challenge_parameters,omitempty"`
+}
+
+type ChallengeResponse struct {
+	Email         string `json:"email" binding:"required,email,max=256"`
+	ChallengeName string `json:"challenge_name" binding:"required,oneof=NEW_PASSWORD_REQUIRED SMS_MFA SOFTWARE_TOKEN_MFA SELECT_MFA_TYPE"`
+	Session       string `json:"session" binding:"required"`
+	// UserID is the challenge's user_id. Cognito expects it rather than the
+	// email once the pool resolved an alias, e.g. after SRP.
+	UserID string `json:"user_id,omitempty" binding:"max=256"`
+	// NewPassword answers NEW_PASSWORD_REQUIRED, together with any required
+	// attributes the pool asks for.
+	NewPassword string            `json:"new_password,omitempty" binding:"omitempty,password,max=256"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	// Code answers SMS_MFA and SOFTWARE_TOKEN_MFA.
+	Code string `json:"code,omitempty" binding:"omitempty,numeric,len=6"`
+	// MFAType answers SELECT_MFA_TYPE with SMS_MFA or SOFTWARE_TOKEN_MFA.
+	MFAType string `json:"mfa_type,omitempty" binding:"omitempty,oneof=SMS_MFA SOFTWARE_TOKEN_MFA"`
+}
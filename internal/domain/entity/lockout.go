@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// Lockout is the failed login record of an email or IP address.
+type Lockout struct {
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	// LockedUntil is in the past once the current delay ran out.
+	LockedUntil time.Time `json:"locked_until"`
+	Locked      bool      `json:"locked"`
+}
+
+type LockoutList struct {
+	Lockouts []Lockout `json:"lockouts"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+type SoftwareTokenAssociation struct {
+	SecretCode  string `json:"secret_code"`
+	AccountName string `json:"account_name"`
+	URI         string `json:"otpauth_uri"`
+}
+
+type VerifySoftwareToken struct {
+	Code       string `json:"code" binding:"required,numeric,len=6"`
+	DeviceName string `json:"device_name,omitempty" binding:"max=128"`
+}
+
+type MFASetting struct {
+	Enabled   bool `json:"enabled"`
+	Preferred bool `json:"preferred"`
+}
+
+// MFAPreference leaves a factor unchanged when it is omitted.
+type MFAPreference struct {
+	TOTP *MFASetting `json:"totp,omitempty"`
+	SMS  *MFASetting `json:"sms,omitempty"`
+}
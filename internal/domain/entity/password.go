@@ -0,0 +1,21 @@
+package entity
+
+// PasswordStrength asks how a password would fare; email and name make the
+// similarity checks match what sign-up will do.
+type PasswordStrength struct {
+	Password string `json:"password" binding:"required,max=256"`
+	Email    string `json:"email,omitempty" binding:"omitempty,max=256"`
+	Name     string `json:"name,omitempty" binding:"omitempty,max=256"`
+}
+
+type PasswordViolation struct {
+	Rule    string `json:"rule" example:"breached"`
+	Message string `json:"message" example:"Password appears in a list of breached passwords"`
+}
+
+type PasswordStrengthResult struct {
+	// Score grows from 0, trivially guessable, to 4, very strong.
+	Score      int                 `json:"score" example:"3"`
+	Acceptable bool                `json:"acceptable"`
+	Violations []PasswordViolation `json:"violations"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// User is a user as the identity provider stores it.
+type User struct {
+	Username   string
+	Attributes map[string]string
+}
+
+type UserProfile struct {
+	Sub                 string `json:"sub"`
+	Username            string `json:"username"`
+	Email               string `json:"email,omitempty"`
+	EmailVerified       *bool  `json:"email_verified,omitempty"`
+	Name                string `json:"name,omitempty"`
+	PhoneNumber         string `json:"phone_number,omitempty"`
+	PhoneNumberVerified *bool  `json:"phone_number_verified,omitempty"`
+	// Attributes holds every other readable attribute, custom:* ones included.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type UpdateProfile struct {
+	Attributes map[string]string `json:"attributes" binding:"required,min=1"`
+}
+
+type VerifyEmail struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
+}
+
+type DeleteAccount struct {
+	Password string `json:"password" binding:"required,max=256"`
+}
+
+type AccountDeletion struct {
+	DeleteAt time.Time `json:"delete_at"`
+}
@@ -1,9 +1,23 @@
 package entity
 
+import "github.com/Zeta-Manu/manu-auth/pkg/utils"
+
 type ResponseWrapper struct {
 	Data interface{} `json:"data"`
 }
 
-type ErrorWrapper struct {
-	Error error `json:"error"`
+// Problem is an RFC 7807 error response, served as application/problem+json.
+type Problem struct {
+	// Type identifies the kind of problem, /problems/ followed by the code.
+	Type   string `json:"type" example:"/problems/auth-code-expired"`
+	Title  string `json:"title" example:"Bad Request"`
+	Status int    `json:"status" example:"400"`
+	Detail string `json:"detail,omitempty" example:"Verification code expired"`
+	// Instance is the path of the request that failed.
+	Instance string `json:"instance,omitempty" example:"/api/v2/confirm"`
+	// Code is stable across releases, switch on it rather than on detail.
+	Code      string `json:"code" example:"AUTH_CODE_EXPIRED"`
+	RequestID string `json:"request_id" example:"4f9c1b0e8a2d4c6b9e7f1a3d5c8b2e60"`
+	// Errors lists the broken rules per field when code is AUTH_VALIDATION_FAILED.
+	Errors []utils.FieldError `json:"errors,omitempty"`
 }
@@ -1,34 +1,46 @@
 package entity
 
 type UserRegistration struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" binding:"required,max=256"`
+	Email    string `json:"email" binding:"required,email,max=256"`
+	Password string `json:"password" binding:"required,password,max=256"`
 }
 
+// UserLogin skips the password policy: older passwords may predate it.
 type UserLogin struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" binding:"required,email,max=256"`
+	Password string `json:"password" binding:"required,max=256"`
 }
 
 type UserRegistrationConfirm struct {
-	Email            string `json:"email"`
-	ConfirmationCode string `json:"confirmation_code"`
+	Email            string `json:"email" binding:"required,email,max=256"`
+	ConfirmationCode string `json:"confirmation_code" binding:"required,numeric,len=6"`
 }
 
 type UserResetPassword struct {
-	Email            string `json:"email"`
-	ConfirmationCode string `json:"confirmation_code"`
-	NewPassword      string `json:"new_password"`
+	Email            string `json:"email" binding:"required,email,max=256"`
+	ConfirmationCode string `json:"confirmation_code" binding:"required,numeric,len=6"`
+	NewPassword      string `json:"new_password" binding:"required,password,max=256"`
 }
 
 type UserChangePassword struct {
-	PreviousPassword string `json:"previous_password"`
-	ProposedPassword string `json:"proposed_password"`
+	PreviousPassword string `json:"previous_password" binding:"required,max=256"`
+	ProposedPassword string `json:"proposed_password" binding:"required,password,max=256"`
+}
+
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	// AccessToken, possibly expired, is only needed by app clients with a
+	// secret: their SECRET_HASH is computed over the sub it carries.
+	AccessToken string `json:"access_token,omitempty"`
+}
+
+type Logout struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type Email struct {
-	Email string `json:"email"`
+	Email string `json:"email" binding:"required,email,max=256"`
 }
 
 type LoginResult struct {
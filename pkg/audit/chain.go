@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// computeHash is the HMAC-SHA256 under key of every field of event except
+// Hash itself. PrevHash is included, so changing, removing or reordering an
+// entry breaks every hash after it, and without the key nobody can forge a
+// chain that verifies.
+func computeHash(key []byte, event Event) (string, error) {
+	event.Hash = ""
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify checks a JSON-lines trail, such as rotated files concatenated from
+// oldest to newest, and returns how many events it read. The first event
+// may link to one that was rotated away; after that every event must follow
+// the previous one. Checkpoints, whether passed in or found among the lines,
+// must match the event with their Seq, and one past the last event means the
+// end of the trail was cut off.
+func Verify(r io.Reader, key []byte, checkpoints ...Checkpoint) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var first, prev *Event
+	hashes := make(map[uint64]string)
+	count := 0
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return count, fmt.Errorf("line %d: %w", count+1, err)
+		}
+		if event.Type == TypeCheckpoint {
+			checkpoints = append(checkpoints, Checkpoint{Seq: event.Seq, Hash: event.Hash})
+			continue
+		}
+
+		hash, err := computeHash(key, event)
+		if err != nil {
+			return count, err
+		}
+		if !hmac.Equal([]byte(hash), []byte(event.Hash)) {
+			return count, fmt.Errorf("event %d: hash mismatch, entry was modified or the key is wrong", event.Seq)
+		}
+		if prev != nil && (event.PrevHash != prev.Hash || event.Seq != prev.Seq+1) {
+			return count, fmt.Errorf("event %d: does not follow event %d, entries were removed or reordered", event.Seq, prev.Seq)
+		}
+		if prev == nil && event.Seq == 1 && event.PrevHash != "" {
+			return count, fmt.Errorf("event 1: first event links to a previous one")
+		}
+
+		if first == nil {
+			first = &event
+		}
+		prev = &event
+		hashes[event.Seq] = event.Hash
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, err
+	}
+
+	for _, checkpoint := range checkpoints {
+		// Events before the first one read were rotated away.
+		if first != nil && checkpoint.Seq < first.Seq {
+			continue
+		}
+		hash, ok := hashes[checkpoint.Seq]
+		if !ok {
+			return count, fmt.Errorf("checkpoint %d: trail ends before it, entries were removed", checkpoint.Seq)
+		}
+		if hash != checkpoint.Hash {
+			return count, fmt.Errorf("checkpoint %d: hash differs from the event, the trail was rewritten", checkpoint.Seq)
+		}
+	}
+	return count, nil
+}
+
+// LastEvent reads the newest event of a JSON-lines trail so a restarted
+// Trail continues its chain. A missing or empty file returns the zero Event.
+func LastEvent(path string) (Event, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Event{}, nil
+	}
+	if err != nil {
+		return Event{}, err
+	}
+	defer file.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil || last == nil {
+		return Event{}, err
+	}
+
+	var event Event
+	if err := json.Unmarshal(last, &event); err != nil {
+		return Event{}, fmt.Errorf("last audit event in %s: %w", path, err)
+	}
+	return event, nil
+}
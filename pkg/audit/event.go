@@ -0,0 +1,77 @@
+package audit
+
+import "time"
+
+type Type string
+
+const (
+	TypeSignUp               Type = "signup"
+	TypeConfirmSignUp        Type = "confirm_signup"
+	TypeLogin                Type = "login"
+	TypeChallenge            Type = "challenge"
+	TypeOAuthLogin           Type = "oauth_login"
+	TypeForgotPassword       Type = "forgot_password"
+	TypeConfirmForgot        Type = "confirm_forgot_password"
+	TypeChangePassword       Type = "change_password"
+	TypeLogout               Type = "logout"
+	TypeGlobalSignOut        Type = "global_sign_out"
+	TypeDeleteAccount        Type = "delete_account"
+	TypeAdminDisableUser     Type = "admin.disable_user"
+	TypeAdminEnableUser      Type = "admin.enable_user"
+	TypeAdminResetPassword   Type = "admin.reset_password"
+	TypeAdminResendInvite    Type = "admin.resend_invitation"
+	TypeAdminDeleteUser      Type = "admin.delete_user"
+	TypeAdminCreateGroup     Type = "admin.create_group"
+	TypeAdminAddToGroup      Type = "admin.add_user_to_group"
+	TypeAdminRemoveFromGroup Type = "admin.remove_user_from_group"
+	TypeAdminClearLockout    Type = "admin.clear_lockout"
+	TypeChangeEmail          Type = "change_email"
+	TypeVerifyEmail          Type = "verify_email"
+	TypeResendEmailCode      Type = "resend_email_verification"
+	TypeMFAAssociateTOTP     Type = "mfa.associate_totp"
+	TypeMFAVerifyTOTP        Type = "mfa.verify_totp"
+	TypeMFASetPreference     Type = "mfa.set_preference"
+
+	// TypeCheckpoint marks a Checkpoint rather than an Event.
+	TypeCheckpoint Type = "audit.checkpoint"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event is one entry of the audit trail. Seq, Time, PrevHash and Hash are
+// filled in by Trail.Record.
+type Event struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Type Type      `json:"type"`
+	// Actor is the authenticated caller, empty for anonymous requests such
+	// as sign-up and login.
+	Actor string `json:"actor,omitempty"`
+	// Subject is the account acted on, usually an email or username.
+	Subject   string            `json:"subject,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Outcome   Outcome           `json:"outcome"`
+	ErrorCode string            `json:"error_code,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	// PrevHash is the Hash of the previous event, empty for the first one.
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// Checkpoint is the head of the chain at some point, sent to the sinks that
+// keep it away from the audit file, such as the webhook. Anyone able to
+// rewrite the file can't reach those, so a truncated or rewritten trail no
+// longer matches its checkpoints.
+type Checkpoint struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Seq  uint64    `json:"seq"`
+	Hash string    `json:"hash"`
+}
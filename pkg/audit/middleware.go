@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Zeta-Manu/manu-auth/pkg/middleware"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+const (
+	subjectKey = "audit_subject"
+	detailsKey = "audit_details"
+)
+
+// Middleware records one eventType event per request once the handler is
+// done. Any recorded error makes it a failure, carrying the error's code.
+// Handlers name the subject with SetSubject; without one it is the caller.
+// Put it before rate limiting so rejected requests are recorded too.
+func (t *Trail) Middleware(eventType Type) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// A nil trail means auditing is disabled.
+		if t == nil {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		event := Event{
+			Type:      eventType,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: middleware.GetRequestID(c),
+			Outcome:   OutcomeSuccess,
+		}
+		if identity, ok := middleware.GetIdentity(c); ok {
+			event.Actor = identity.Username
+			if event.Actor == "" {
+				event.Actor = identity.Sub
+			}
+		}
+		event.Subject = c.GetString(subjectKey)
+		if event.Subject == "" {
+			event.Subject = event.Actor
+		}
+		if details, ok := c.Get(detailsKey); ok {
+			event.Details, _ = details.(map[string]string)
+		}
+		if last := c.Errors.Last(); last != nil {
+			event.Outcome = OutcomeFailure
+			event.ErrorCode = utils.ErrCodeInternal
+			var customErr *utils.CustomError
+			if errors.As(last.Err, &customErr) {
+				event.ErrorCode = customErr.Code
+			}
+		}
+
+		t.Record(event)
+	}
+}
+
+// SetSubject names the account the request acts on.
+func SetSubject(c *gin.Context, subject string) {
+	c.Set(subjectKey, subject)
+}
+
+// SetDetail adds context to the event, such as a group name.
+func SetDetail(c *gin.Context, key, value string) {
+	details, _ := c.Get(detailsKey)
+	m, ok := details.(map[string]string)
+	if !ok {
+		m = make(map[string]string)
+		c.Set(detailsKey, m)
+	}
+	m[key] = value
+}
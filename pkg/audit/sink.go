@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Sink stores or forwards events. Write is called from one goroutine at a
+// time, in chain order.
+type Sink interface {
+	Write(event Event) error
+	Close() error
+}
+
+// CheckpointSink is a Sink kept away from the audit file, which also stores
+// the Trail's checkpoints.
+type CheckpointSink interface {
+	Sink
+	WriteCheckpoint(checkpoint Checkpoint) error
+}
+
+// StreamSink writes one JSON object per line, e.g. to os.Stdout.
+type StreamSink struct {
+	w io.Writer
+}
+
+func NewStreamSink(w io.Writer) *StreamSink {
+	return &StreamSink{w: w}
+}
+
+func (s *StreamSink) Write(event Event) error {
+	return s.writeLine(event)
+}
+
+// WriteCheckpoint puts checkpoints among the events; Verify checks them
+// when the stream is replayed.
+func (s *StreamSink) WriteCheckpoint(checkpoint Checkpoint) error {
+	return s.writeLine(checkpoint)
+}
+
+func (s *StreamSink) writeLine(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+func (s *StreamSink) Close() error {
+	return nil
+}
+
+// FileSink appends JSON lines to a file. Once the file would grow past
+// maxSize bytes it is renamed to path.1, path.1 to path.2 and so on, keeping
+// at most maxBackups old files.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink opens path for appending. A maxSize of zero never rotates.
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	s := &FileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) Write(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	if s.maxBackups > 0 {
+		os.Remove(backupName(s.path, s.maxBackups))
+		for i := s.maxBackups - 1; i >= 1; i-- {
+			os.Rename(backupName(s.path, i), backupName(s.path, i+1))
+		}
+		if err := os.Rename(s.path, backupName(s.path, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// WebhookSink posts each event and checkpoint as JSON to a URL, e.g. a SIEM
+// collector. Posts happen on its own goroutine behind a buffer, so a slow
+// collector doesn't hold up the other sinks; once the buffer is full, events
+// are dropped and reported rather than waited for. The file stays complete.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+	logger *zap.Logger
+
+	queue chan interface{}
+	done  chan struct{}
+}
+
+// NewWebhookSink buffers up to size events and checkpoints.
+func NewWebhookSink(url string, client *http.Client, size int, logger *zap.Logger) *WebhookSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s := &WebhookSink{
+		url:    url,
+		client: client,
+		logger: logger,
+		queue:  make(chan interface{}, size),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *WebhookSink) Write(event Event) error {
+	return s.enqueue(event)
+}
+
+func (s *WebhookSink) WriteCheckpoint(checkpoint Checkpoint) error {
+	return s.enqueue(checkpoint)
+}
+
+func (s *WebhookSink) enqueue(v interface{}) error {
+	select {
+	case s.queue <- v:
+		return nil
+	default:
+		return errors.New("audit webhook buffer is full, dropped")
+	}
+}
+
+func (s *WebhookSink) run() {
+	defer close(s.done)
+	for v := range s.queue {
+		if err := s.post(v); err != nil {
+			s.logger.Error("Failed to post audit event", zap.String("URL", s.url), zap.Error(err))
+		}
+	}
+}
+
+func (s *WebhookSink) post(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, s.url)
+	}
+	return nil
+}
+
+// Close posts what is buffered. Write must not be called afterwards.
+func (s *WebhookSink) Close() error {
+	close(s.queue)
+	<-s.done
+	return nil
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Trail chains events and hands them to its sinks. Events are linked and
+// written by a single goroutine, in order, so requests only wait for room in
+// the queue, never for a sink.
+type Trail struct {
+	sinks           []Sink
+	key             []byte
+	checkpointEvery time.Duration
+	logger          *zap.Logger
+
+	// seq and lastHash belong to the run goroutine.
+	seq            uint64
+	lastHash       string
+	checkpointedAt uint64
+
+	events    chan Event
+	closing   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewTrail continues the chain after last, the zero Event for a new trail,
+// keyed with key. Every checkpointEvery the head of the chain is sent to the
+// sinks that take checkpoints; zero only sends one on Close. Close must be
+// called to flush pending events.
+func NewTrail(last Event, key []byte, checkpointEvery time.Duration, logger *zap.Logger, sinks ...Sink) *Trail {
+	t := &Trail{
+		sinks:           sinks,
+		key:             key,
+		checkpointEvery: checkpointEvery,
+		logger:          logger,
+		seq:             last.Seq,
+		lastHash:        last.Hash,
+		checkpointedAt:  last.Seq,
+		events:          make(chan Event, 1024),
+		closing:         make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Record queues event for the chain. It blocks only when the queue is full,
+// as dropping entries would break the chain. Events recorded after Close are
+// dropped.
+func (t *Trail) Record(event Event) {
+	event.Time = time.Now().UTC()
+	select {
+	case t.events <- event:
+	case <-t.closing:
+	}
+}
+
+func (t *Trail) run() {
+	defer close(t.done)
+
+	var tick <-chan time.Time
+	if t.checkpointEvery > 0 {
+		ticker := time.NewTicker(t.checkpointEvery)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case event := <-t.events:
+			t.write(event)
+		case <-tick:
+			t.checkpoint()
+		case <-t.closing:
+			for {
+				select {
+				case event := <-t.events:
+					t.write(event)
+				default:
+					t.checkpoint()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write links event to the chain and hands it to every sink.
+func (t *Trail) write(event Event) {
+	event.Seq = t.seq + 1
+	event.PrevHash = t.lastHash
+	hash, err := computeHash(t.key, event)
+	if err != nil {
+		t.logger.Error("Failed to hash audit event", zap.String("Type", string(event.Type)), zap.Error(err))
+		return
+	}
+	event.Hash = hash
+	t.seq = event.Seq
+	t.lastHash = hash
+
+	for _, sink := range t.sinks {
+		if err := sink.Write(event); err != nil {
+			t.logger.Error("Failed to write audit event", zap.Uint64("Seq", event.Seq), zap.String("Type", string(event.Type)), zap.Error(err))
+		}
+	}
+}
+
+// checkpoint sends the head of the chain, if it moved, to the sinks that
+// keep checkpoints.
+func (t *Trail) checkpoint() {
+	if t.seq == t.checkpointedAt {
+		return
+	}
+	checkpoint := Checkpoint{Type: TypeCheckpoint, Time: time.Now().UTC(), Seq: t.seq, Hash: t.lastHash}
+	for _, sink := range t.sinks {
+		if checkpoints, ok := sink.(CheckpointSink); ok {
+			if err := checkpoints.WriteCheckpoint(checkpoint); err != nil {
+				t.logger.Error("Failed to write audit checkpoint", zap.Uint64("Seq", checkpoint.Seq), zap.Error(err))
+			}
+		}
+	}
+	t.checkpointedAt = t.seq
+}
+
+// Close writes the queued events and a last checkpoint, then closes the
+// sinks.
+func (t *Trail) Close() {
+	t.closeOnce.Do(func() {
+		close(t.closing)
+		<-t.done
+		for _, sink := range t.sinks {
+			if err := sink.Close(); err != nil {
+				t.logger.Error("Failed to close audit sink", zap.Error(err))
+			}
+		}
+	})
+}
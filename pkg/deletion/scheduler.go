@@ -0,0 +1,202 @@
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+const DefaultGracePeriod = 30 * 24 * time.Hour
+
+// DeleteFunc permanently deletes the user with the given username.
+type DeleteFunc func(ctx context.Context, username string) error
+
+// Pending is an account waiting for its grace period to run out.
+type Pending struct {
+	Email       string    `json:"email"`
+	Username    string    `json:"username"`
+	RequestedAt time.Time `json:"requested_at"`
+	DeleteAt    time.Time `json:"delete_at"`
+}
+
+// Scheduler deletes accounts once their grace period is over. Pending
+// deletions are keyed by email, the login name, and written to a file on
+// every change so they survive restarts.
+type Scheduler struct {
+	file        string
+	gracePeriod time.Duration
+	deleteUser  DeleteFunc
+	logger      *zap.Logger
+
+	mu      sync.Mutex
+	pending map[string]Pending
+}
+
+// NewScheduler loads the pending deletions from file. An empty file name
+// keeps them in memory only.
+func NewScheduler(file string, gracePeriod time.Duration, deleteUser DeleteFunc, logger *zap.Logger) (*Scheduler, error) {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultGracePeriod
+	}
+
+	s := &Scheduler{
+		file:        file,
+		gracePeriod: gracePeriod,
+		deleteUser:  deleteUser,
+		logger:      logger,
+		pending:     make(map[string]Pending),
+	}
+	if file != "" {
+		if err := s.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Schedule records that the account should be deleted after the grace period.
+func (s *Scheduler) Schedule(email, username string) (Pending, error) {
+	now := time.Now().UTC()
+	pending := Pending{
+		Email:       email,
+		Username:    username,
+		RequestedAt: now,
+		DeleteAt:    now.Add(s.gracePeriod),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := normalize(email)
+	previous, existed := s.pending[key]
+	s.pending[key] = pending
+	if err := s.save(); err != nil {
+		if existed {
+			s.pending[key] = previous
+		} else {
+			delete(s.pending, key)
+		}
+		return Pending{}, err
+	}
+	return pending, nil
+}
+
+func (s *Scheduler) Lookup(email string) (Pending, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, ok := s.pending[normalize(email)]
+	return pending, ok
+}
+
+// Cancel drops the pending deletion for email, if any.
+func (s *Scheduler) Cancel(email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := normalize(email)
+	pending, ok := s.pending[key]
+	if !ok {
+		return nil
+	}
+	delete(s.pending, key)
+	if err := s.save(); err != nil {
+		s.pending[key] = pending
+		return err
+	}
+	return nil
+}
+
+// Start deletes due accounts every interval until ctx is cancelled. Failed
+// deletions are retried on the next tick.
+func (s *Scheduler) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.deleteDue(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) deleteDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	var due []Pending
+	for _, pending := range s.pending {
+		if !now.Before(pending.DeleteAt) {
+			due = append(due, pending)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, pending := range due {
+		if err := s.deleteUser(ctx, pending.Username); err != nil {
+			s.logger.Error("Failed to delete account", zap.String("username", pending.Username), zap.Error(err))
+			continue
+		}
+
+		s.mu.Lock()
+		// The user may have cancelled and asked again while the call was running.
+		if current, ok := s.pending[normalize(pending.Email)]; ok && current.RequestedAt.Equal(pending.RequestedAt) {
+			delete(s.pending, normalize(pending.Email))
+			if err := s.save(); err != nil {
+				s.logger.Error("Failed to persist pending deletions", zap.String("file", s.file), zap.Error(err))
+			}
+		}
+		s.mu.Unlock()
+		s.logger.Info("Account deleted", zap.String("username", pending.Username))
+	}
+}
+
+// save must be called with s.mu held.
+func (s *Scheduler) save() error {
+	if s.file == "" {
+		return nil
+	}
+
+	list := make([]Pending, 0, len(s.pending))
+	for _, pending := range s.pending {
+		list = append(list, pending)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].DeleteAt.Before(list[j].DeleteAt) })
+
+	body, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(s.file, body)
+}
+
+func (s *Scheduler) load() error {
+	body, err := os.ReadFile(s.file)
+	if err != nil {
+		return err
+	}
+
+	var list []Pending
+	if err := json.Unmarshal(body, &list); err != nil {
+		return err
+	}
+	for _, pending := range list {
+		s.pending[normalize(pending.Email)] = pending
+	}
+	return nil
+}
+
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
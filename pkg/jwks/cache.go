@@ -0,0 +1,289 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/httpcc"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/Zeta-Manu/manu-auth/pkg/metrics"
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+const (
+	DefaultRefreshInterval    = 15 * time.Minute
+	DefaultMinRefreshInterval = 30 * time.Second
+	DefaultFetchTimeout       = 10 * time.Second
+)
+
+var (
+	ErrKeyNotFound = errors.New("jwks: key not found")
+	ErrUnavailable = errors.New("jwks: key set unavailable")
+)
+
+// KeySource resolves the public key a token was signed with.
+type KeySource interface {
+	LookupKeyID(ctx context.Context, kid string) (jwk.Key, error)
+}
+
+type Options struct {
+	// RefreshInterval is the upper bound between background refreshes.
+	// A shorter Cache-Control max-age from the endpoint takes precedence.
+	RefreshInterval time.Duration
+	// MinRefreshInterval is the lower bound between two fetches, whether
+	// scheduled or triggered by an unknown kid.
+	MinRefreshInterval time.Duration
+	// CacheFile, when set, keeps the last good key set on disk so a cold start
+	// can verify tokens before the endpoint has been reached.
+	CacheFile  string
+	HTTPClient *http.Client
+}
+
+// Cache keeps a JWKS in memory and refreshes it in the background. The last
+// successfully fetched set keeps being served while the endpoint is unreachable.
+type Cache struct {
+	url    string
+	opts   Options
+	logger *zap.Logger
+	group  singleflight.Group
+
+	mu          sync.RWMutex
+	set         jwk.Set
+	lastFetch   time.Time
+	nextRefresh time.Time
+}
+
+func NewCache(url string, opts Options, logger *zap.Logger) *Cache {
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = DefaultRefreshInterval
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = DefaultMinRefreshInterval
+	}
+	if opts.MinRefreshInterval > opts.RefreshInterval {
+		opts.MinRefreshInterval = opts.RefreshInterval
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: DefaultFetchTimeout}
+	}
+
+	return &Cache{
+		url:    url,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Start loads the persisted set, performs the first fetch and keeps refreshing
+// until ctx is cancelled. A failed first fetch is logged, not returned, so the
+// service can still come up during an outage.
+func (c *Cache) Start(ctx context.Context) {
+	if c.opts.CacheFile != "" {
+		if err := c.loadFile(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			c.logger.Warn("Failed to load persisted JWKS", zap.String("file", c.opts.CacheFile), zap.Error(err))
+		}
+	}
+
+	if _, err := c.refresh(); err != nil {
+		c.logger.Error("Initial JWKS fetch failed", zap.String("url", c.url), zap.Error(err))
+	}
+
+	go c.run(ctx)
+}
+
+func (c *Cache) LookupKeyID(ctx context.Context, kid string) (jwk.Key, error) {
+	set := c.current()
+	if set != nil {
+		if key, ok := set.LookupKeyID(kid); ok {
+			metrics.JWKSLookups.WithLabelValues("hit").Inc()
+			return key, nil
+		}
+	}
+
+	// The kid may belong to a freshly rotated key; refetch once, unless we
+	// fetched very recently.
+	if c.canForceRefresh() {
+		refreshed, err := c.refresh()
+		if err != nil {
+			c.logger.Warn("JWKS refresh on unknown kid failed", zap.String("kid", kid), zap.Error(err))
+		}
+		if refreshed != nil {
+			set = refreshed
+		}
+	}
+
+	if set == nil {
+		metrics.JWKSLookups.WithLabelValues("unavailable").Inc()
+		return nil, ErrUnavailable
+	}
+	if key, ok := set.LookupKeyID(kid); ok {
+		metrics.JWKSLookups.WithLabelValues("refreshed").Inc()
+		return key, nil
+	}
+	metrics.JWKSLookups.WithLabelValues("not_found").Inc()
+	return nil, ErrKeyNotFound
+}
+
+func (c *Cache) run(ctx context.Context) {
+	for {
+		c.mu.RLock()
+		wait := time.Until(c.nextRefresh)
+		c.mu.RUnlock()
+		if wait < c.opts.MinRefreshInterval {
+			wait = c.opts.MinRefreshInterval
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := c.refresh(); err != nil {
+			c.logger.Warn("JWKS background refresh failed, serving cached keys", zap.String("url", c.url), zap.Error(err))
+		}
+	}
+}
+
+func (c *Cache) current() jwk.Set {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.set
+}
+
+func (c *Cache) canForceRefresh() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Since(c.lastFetch) >= c.opts.MinRefreshInterval
+}
+
+// refresh fetches the set; concurrent callers share a single request. The
+// request is not tied to any caller's context, so one aborted HTTP request
+// cannot fail the refresh for everyone waiting on it.
+func (c *Cache) refresh() (jwk.Set, error) {
+	v, err, _ := c.group.Do("refresh", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultFetchTimeout)
+		defer cancel()
+
+		start := time.Now()
+		set, err := c.fetch(ctx)
+		metrics.JWKSFetchDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.JWKSFetches.WithLabelValues("error").Inc()
+			return nil, err
+		}
+		metrics.JWKSFetches.WithLabelValues("success").Inc()
+		metrics.JWKSLastSuccess.SetToCurrentTime()
+		metrics.JWKSKeys.Set(float64(set.Len()))
+		return set, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(jwk.Set), nil
+}
+
+func (c *Cache) fetch(ctx context.Context) (jwk.Set, error) {
+	c.mu.Lock()
+	c.lastFetch = time.Now()
+	// Until the fetch succeeds, retry at the minimum interval.
+	c.nextRefresh = c.lastFetch.Add(c.opts.MinRefreshInterval)
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, c.url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := jwk.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.set = set
+	c.nextRefresh = time.Now().Add(c.refreshInterval(resp.Header.Get("Cache-Control")))
+	c.mu.Unlock()
+
+	if c.opts.CacheFile != "" {
+		if err := utils.WriteFileAtomic(c.opts.CacheFile, body); err != nil {
+			c.logger.Warn("Failed to persist JWKS", zap.String("file", c.opts.CacheFile), zap.Error(err))
+		}
+	}
+
+	return set, nil
+}
+
+func (c *Cache) refreshInterval(cacheControl string) time.Duration {
+	if cacheControl == "" {
+		return c.opts.RefreshInterval
+	}
+
+	directive, err := httpcc.ParseResponse(cacheControl)
+	if err != nil {
+		return c.opts.RefreshInterval
+	}
+	if directive.NoStore() {
+		return c.opts.MinRefreshInterval
+	}
+
+	maxAge, ok := directive.MaxAge()
+	if !ok {
+		return c.opts.RefreshInterval
+	}
+
+	interval := time.Duration(maxAge) * time.Second
+	switch {
+	case interval < c.opts.MinRefreshInterval:
+		return c.opts.MinRefreshInterval
+	case interval > c.opts.RefreshInterval:
+		return c.opts.RefreshInterval
+	default:
+		return interval
+	}
+}
+
+func (c *Cache) loadFile() error {
+	body, err := os.ReadFile(c.opts.CacheFile)
+	if err != nil {
+		return err
+	}
+
+	set, err := jwk.Parse(body)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.set == nil {
+		c.set = set
+	}
+	c.mu.Unlock()
+	return nil
+}
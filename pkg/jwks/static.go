@@ -0,0 +1,23 @@
+package jwks
+
+import (
+	"context"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// Static serves a fixed key set, e.g. the one of the in-memory identity provider.
+type Static struct {
+	set jwk.Set
+}
+
+func NewStatic(set jwk.Set) *Static {
+	return &Static{set: set}
+}
+
+func (s *Static) LookupKeyID(ctx context.Context, kid string) (jwk.Key, error) {
+	if key, ok := s.set.LookupKeyID(kid); ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
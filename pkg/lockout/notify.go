@@ -0,0 +1,44 @@
+package lockout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier posts the email and its failure record as JSON to url, e.g.
+// a mailer that writes to the account owner.
+func WebhookNotifier(url string, client *http.Client) NotifyFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context, email string, entry Entry) error {
+		body, err := json.Marshal(struct {
+			Email string `json:"email"`
+			Entry
+		}{Email: email, Entry: entry})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,275 @@
+package lockout
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultResetAfter is used when a policy does not say when to forget failures.
+const DefaultResetAfter = time.Hour
+
+type Kind string
+
+const (
+	KindEmail Kind = "email"
+	KindIP    Kind = "ip"
+)
+
+// Policy turns a run of failed logins into a delay. The first FreeAttempts
+// failures cost nothing; with zero, backoff starts at the first failure.
+// Every further failure doubles the delay starting at BaseDelay up to
+// MaxDelay, and LockoutAfter failures lock the key for LockoutDuration. A key
+// is forgotten ResetAfter its last failure.
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration
+}
+
+// Enabled reports whether the policy ever delays a login.
+func (p Policy) Enabled() bool {
+	return p.BaseDelay > 0 || p.LockoutAfter > 0
+}
+
+func (p Policy) delay(failures int) time.Duration {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration
+	}
+	free := p.FreeAttempts
+	if free < 0 {
+		free = 0
+	}
+	if failures <= free || p.BaseDelay <= 0 {
+		return 0
+	}
+
+	// Cap the exponent; anything past it is above any sensible MaxDelay anyway.
+	exponent := math.Min(float64(failures-free-1), 30)
+	delay := time.Duration(float64(p.BaseDelay) * math.Pow(2, exponent))
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// NotifyFunc tells the owner of email that their account is under attack.
+type NotifyFunc func(ctx context.Context, email string, entry Entry) error
+
+// Entry is the failure record of one email or IP address.
+type Entry struct {
+	Kind        Kind      `json:"kind"`
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (e Entry) locked(now time.Time) bool {
+	return now.Before(e.LockedUntil)
+}
+
+// Tracker counts failed logins per email and per IP address. State is kept in
+// memory, so each replica tracks the attempts it has seen.
+type Tracker struct {
+	perEmail    Policy
+	perIP       Policy
+	notifyAfter int
+	notify      NotifyFunc
+	logger      *zap.Logger
+
+	mu      sync.Mutex
+	entries map[Kind]map[string]*Entry
+}
+
+// NewTracker notifies the account owner once notifyAfter consecutive failures
+// were recorded for their email. A zero notifyAfter or nil notify disables it.
+func NewTracker(perEmail, perIP Policy, notifyAfter int, notify NotifyFunc, logger *zap.Logger) *Tracker {
+	if perEmail.ResetAfter <= 0 {
+		perEmail.ResetAfter = DefaultResetAfter
+	}
+	if perIP.ResetAfter <= 0 {
+		perIP.ResetAfter = DefaultResetAfter
+	}
+	return &Tracker{
+		perEmail:    perEmail,
+		perIP:       perIP,
+		notifyAfter: notifyAfter,
+		notify:      notify,
+		logger:      logger,
+		entries: map[Kind]map[string]*Entry{
+			KindEmail: make(map[string]*Entry),
+			KindIP:    make(map[string]*Entry),
+		},
+	}
+}
+
+// Check reports how long a login for email from ip has to wait, if at all.
+func (t *Tracker) Check(email, ip string) (time.Duration, bool) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var wait time.Duration
+	for kind, key := range map[Kind]string{KindEmail: normalize(email), KindIP: ip} {
+		if entry, ok := t.entries[kind][key]; ok && entry.locked(now) {
+			if remaining := entry.LockedUntil.Sub(now); remaining > wait {
+				wait = remaining
+			}
+		}
+	}
+	return wait, wait > 0
+}
+
+// Failure records a failed login for email from ip.
+func (t *Tracker) Failure(email, ip string) {
+	email = normalize(email)
+
+	t.mu.Lock()
+	emailEntry := t.record(KindEmail, email, t.perEmail)
+	t.record(KindIP, ip, t.perIP)
+	t.mu.Unlock()
+
+	if emailEntry == nil || t.notify == nil || t.notifyAfter <= 0 || emailEntry.Failures != t.notifyAfter {
+		return
+	}
+	t.logger.Warn("Repeated failed logins, notifying account owner", zap.String("Email", email), zap.Int("Failures", emailEntry.Failures))
+	// Don't hold up the response for the notification.
+	go func(entry Entry) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := t.notify(ctx, email, entry); err != nil {
+			t.logger.Error("Failed to notify account owner of failed logins", zap.String("Email", email), zap.Error(err))
+		}
+	}(*emailEntry)
+}
+
+// record must be called with t.mu held. It returns a copy of the updated entry.
+func (t *Tracker) record(kind Kind, key string, policy Policy) *Entry {
+	if key == "" || !policy.Enabled() {
+		return nil
+	}
+	now := time.Now().UTC()
+
+	entry, ok := t.entries[kind][key]
+	if !ok || t.expired(entry, policy, now) {
+		entry = &Entry{Kind: kind, Key: key}
+		t.entries[kind][key] = entry
+	}
+	entry.Failures++
+	entry.LastFailure = now
+	if delay := policy.delay(entry.Failures); delay > 0 {
+		entry.LockedUntil = now.Add(delay)
+	}
+
+	copied := *entry
+	return &copied
+}
+
+// Success forgets the failures of email. Those of the IP address stay, so one
+// valid account does not unlock guessing at others.
+func (t *Tracker) Success(email string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries[KindEmail], normalize(email))
+}
+
+// List returns the emails and IP addresses with recent failures, locked
+// ones first.
+func (t *Tracker) List() []Entry {
+	now := time.Now()
+
+	t.mu.Lock()
+	entries := make([]Entry, 0, len(t.entries[KindEmail])+len(t.entries[KindIP]))
+	for kind, byKey := range t.entries {
+		for _, entry := range byKey {
+			if !t.expired(entry, t.policy(kind), now) {
+				entries = append(entries, *entry)
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].locked(now) != entries[j].locked(now) {
+			return entries[i].locked(now)
+		}
+		return entries[i].LastFailure.After(entries[j].LastFailure)
+	})
+	return entries
+}
+
+// Clear forgets the failures of an email or IP address and reports whether
+// there were any.
+func (t *Tracker) Clear(kind Kind, key string) bool {
+	if kind == KindEmail {
+		key = normalize(key)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	byKey, ok := t.entries[kind]
+	if !ok {
+		return false
+	}
+	if _, ok := byKey[key]; !ok {
+		return false
+	}
+	delete(byKey, key)
+	return true
+}
+
+// Start prunes forgotten entries until ctx is cancelled.
+func (t *Tracker) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.prune()
+			}
+		}
+	}()
+}
+
+func (t *Tracker) prune() {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for kind, byKey := range t.entries {
+		for key, entry := range byKey {
+			if t.expired(entry, t.policy(kind), now) {
+				delete(byKey, key)
+			}
+		}
+	}
+}
+
+func (t *Tracker) policy(kind Kind) Policy {
+	if kind == KindIP {
+		return t.perIP
+	}
+	return t.perEmail
+}
+
+// expired reports whether the entry is neither locked nor recent enough to count.
+func (t *Tracker) expired(entry *Entry, policy Policy, now time.Time) bool {
+	return !entry.locked(now) && now.Sub(entry.LastFailure) > policy.ResetAfter
+}
+
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,95 @@
+// Package metrics holds the Prometheus collectors of the service. They are
+// registered on Registry, served by Handler on the internal listener.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "manu_auth"
+
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	CognitoRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cognito_request_duration_seconds",
+		Help:      "Cognito API call latency by operation, retries included.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	CognitoErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cognito_errors_total",
+		Help:      "Failed Cognito API calls by operation and mapped error code.",
+	}, []string{"operation", "code"})
+
+	JWKSFetches = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jwks_fetches_total",
+		Help:      "JWKS fetches by result, success or error.",
+	}, []string{"result"})
+
+	JWKSFetchDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "jwks_fetch_duration_seconds",
+		Help:      "JWKS fetch latency.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	JWKSLastSuccess = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "jwks_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful JWKS fetch.",
+	})
+
+	JWKSKeys = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "jwks_keys",
+		Help:      "Keys in the cached JWKS.",
+	})
+
+	JWKSLookups = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jwks_lookups_total",
+		Help:      "Key lookups by result: hit, refreshed, not_found or unavailable.",
+	}, []string{"result"})
+
+	TokenVerificationFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_verification_failures_total",
+		Help:      "Rejected bearer tokens by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -1,95 +1,46 @@
 package middleware
 
 import (
-	"context"
 	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/lestrrat-go/jwx/v2/jwk"
 
+	"github.com/Zeta-Manu/manu-auth/pkg/jwks"
+	"github.com/Zeta-Manu/manu-auth/pkg/metrics"
 	"github.com/Zeta-Manu/manu-auth/pkg/utils"
 )
 
-func AuthenticationMiddleware(jwtPublicKey string) gin.HandlerFunc {
+func AuthenticationMiddleware(validator *TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token, err := utils.ParseToken(c.Request)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			metrics.TokenVerificationFailures.WithLabelValues("missing").Inc()
+			c.Error(&utils.CustomError{Message: err.Error(), Status: http.StatusUnauthorized, Code: utils.ErrCodeTokenMissing})
 			c.Abort()
 			return
 		}
 
-		// Fetch the public JWK from the Cognito endpoint
-		keySet, err := fetchPublicJWTKey(context.Background(), jwtPublicKey)
+		// Verify the signature and claims against the cached public JWKs
+		claims, err := validator.Validate(c.Request.Context(), token)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch public JWK"})
-			c.Abort()
-			return
-		}
-
-		// Verify the Token
-		validToken, err := verifyToken(token, keySet)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token"})
-			c.Abort()
-			return
-		}
-
-		if !validToken.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token"})
-			c.Abort()
-			return
+			metrics.TokenVerificationFailures.WithLabelValues(failureReason(err)).Inc()
 		}
-
-		// Extract the subject (sub) claim from the token
-		claims, ok := validToken.Claims.(jwt.MapClaims)
-		if !ok || !validToken.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token"})
+		if errors.Is(err, jwks.ErrUnavailable) {
+			c.Error(&utils.CustomError{Message: "Failed to fetch public JWK", Status: http.StatusInternalServerError, Code: utils.ErrCodeIdPUnavailable, Err: err})
 			c.Abort()
 			return
 		}
-
-		sub, ok := claims["sub"].(string)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token: Subject not found"})
+		if err != nil {
+			c.Error(&utils.CustomError{Message: "Invalid Token: " + err.Error(), Status: http.StatusUnauthorized, Code: utils.ErrCodeTokenInvalid})
 			c.Abort()
 			return
 		}
 
 		c.Set("token", token)
-		c.Set("sub", sub)
+		c.Set("sub", claims["sub"])
+		c.Set("claims", claims)
+		c.Set(identityKey, NewIdentity(claims))
 		c.Next()
 	}
 }
-
-func verifyToken(tokenString string, keySet jwk.Set) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		kid, ok := token.Header["kid"].(string)
-		if !ok {
-			return nil, errors.New("kid header not found")
-		}
-		keys, ok := keySet.LookupKeyID(kid)
-		if !ok {
-			return nil, errors.New("cannot look up kid header")
-		}
-		var publickey interface{}
-		if err := keys.Raw(&publickey); err != nil {
-			return nil, err
-		}
-		return publickey, nil
-	})
-	if err != nil {
-		return nil, err
-	}
-	return token, nil
-}
-
-func fetchPublicJWTKey(ctx context.Context, link string) (jwk.Set, error) {
-	keySet, err := jwk.Fetch(ctx, link)
-	if err != nil {
-		return nil, err
-	}
-	return keySet, nil
-}
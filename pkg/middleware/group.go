@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Zeta-Manu/manu-auth/pkg/utils"
+)
+
+// Match selects whether a requirement is met by any or only by all of the
+// listed groups or scopes.
+type Match int
+
+const (
+	MatchAny Match = iota
+	MatchAll
+)
+
+// RequireGroups only lets callers through whose cognito:groups satisfy the
+// requirement. It must run after AuthenticationMiddleware.
+func RequireGroups(match Match, groups ...string) gin.HandlerFunc {
+	return require("groups", match, groups, (*Identity).InGroup)
+}
+
+// RequireScopes only lets callers through whose token scope satisfies the
+// requirement. Only access tokens carry scopes.
+func RequireScopes(match Match, scopes ...string) gin.HandlerFunc {
+	return require("scopes", match, scopes, (*Identity).HasScope)
+}
+
+func require(kind string, match Match, required []string, has func(*Identity, string) bool) gin.HandlerFunc {
+	reason := "Forbidden: requires " + kind + " " + strings.Join(required, ", ")
+	if match == MatchAny && len(required) > 1 {
+		reason = "Forbidden: requires one of " + kind + " " + strings.Join(required, ", ")
+	}
+
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if !ok {
+			c.Error(&utils.CustomError{Message: "Unauthorized", Status: http.StatusUnauthorized, Code: utils.ErrCodeNotAuthorized})
+			c.Abort()
+			return
+		}
+
+		met := 0
+		for _, value := range required {
+			if has(identity, value) {
+				met++
+			}
+		}
+
+		satisfied := met == len(required)
+		if match == MatchAny && len(required) > 0 {
+			satisfied = met > 0
+		}
+		if !satisfied {
+			c.Error(&utils.CustomError{Message: reason, Status: http.StatusForbidden, Code: utils.ErrCodeForbidden})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const identityKey = "identity"
+
+// Identity is the caller as described by a validated token.
+type Identity struct {
+	Sub      string
+	Username string
+	Groups   []string
+	Scopes   []string
+	AuthTime time.Time
+	ClientID string
+	TokenUse string
+}
+
+// NewIdentity reads an Identity from validated claims. Access and ID tokens
+// name the username and the client differently; both are understood.
+func NewIdentity(claims jwt.MapClaims) *Identity {
+	identity := &Identity{}
+	identity.Sub, _ = claims["sub"].(string)
+	identity.TokenUse, _ = claims["token_use"].(string)
+
+	if identity.TokenUse == TokenUseAccess {
+		identity.Username, _ = claims["username"].(string)
+		identity.ClientID, _ = claims["client_id"].(string)
+	} else {
+		identity.Username, _ = claims["cognito:username"].(string)
+		if audience, err := claims.GetAudience(); err == nil && len(audience) > 0 {
+			identity.ClientID = audience[0]
+		}
+	}
+
+	if groups, ok := claims["cognito:groups"].([]interface{}); ok {
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		identity.Scopes = strings.Fields(scope)
+	}
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		identity.AuthTime = time.Unix(int64(authTime), 0)
+	}
+
+	return identity
+}
+
+func (i *Identity) InGroup(group string) bool {
+	return contains(i.Groups, group)
+}
+
+func (i *Identity) HasScope(scope string) bool {
+	return contains(i.Scopes, scope)
+}
+
+// GetIdentity returns the Identity AuthenticationMiddleware stored for the request.
+func GetIdentity(c *gin.Context) (*Identity, bool) {
+	value, exists := c.Get(identityKey)
+	if !exists {
+		return nil, false
+	}
+	identity, ok := value.(*Identity)
+	return identity, ok
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
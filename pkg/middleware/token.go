@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Zeta-Manu/manu-auth/pkg/jwks"
+)
+
+const (
+	TokenUseAccess = "access"
+	TokenUseID     = "id"
+)
+
+// Cognito signs every token with RS256; anything else is rejected outright.
+var allowedSigningMethods = []string{jwt.SigningMethodRS256.Alg()}
+
+var (
+	ErrTokenMalformed        = errors.New("malformed token")
+	ErrTokenUnknownKey       = errors.New("unknown signing key")
+	ErrTokenInvalidSignature = errors.New("invalid signature")
+	ErrTokenExpired          = errors.New("token expired")
+	ErrTokenNotYetValid      = errors.New("token not yet valid")
+	ErrTokenInvalidIssuer    = errors.New("invalid issuer")
+	ErrTokenInvalidAudience  = errors.New("invalid audience")
+	ErrTokenInvalidTokenUse  = errors.New("invalid token use")
+	ErrTokenMissingSubject   = errors.New("subject not found")
+	ErrTokenRevoked          = errors.New("token revoked")
+)
+
+// failureReasons labels token verification failures in metrics.
+var failureReasons = map[error]string{
+	ErrTokenMalformed:        "malformed",
+	ErrTokenUnknownKey:       "unknown_key",
+	ErrTokenInvalidSignature: "invalid_signature",
+	ErrTokenExpired:          "expired",
+	ErrTokenNotYetValid:      "not_yet_valid",
+	ErrTokenInvalidIssuer:    "invalid_issuer",
+	ErrTokenInvalidAudience:  "invalid_audience",
+	ErrTokenInvalidTokenUse:  "invalid_token_use",
+	ErrTokenMissingSubject:   "missing_subject",
+	ErrTokenRevoked:          "revoked",
+	jwks.ErrUnavailable:      "jwks_unavailable",
+}
+
+func failureReason(err error) string {
+	if reason, ok := failureReasons[err]; ok {
+		return reason
+	}
+	return "other"
+}
+
+// RevocationChecker reports tokens that were logged out before they expired.
+type RevocationChecker interface {
+	IsRevoked(jti, sub string, issuedAt time.Time) bool
+}
+
+type ValidationOptions struct {
+	// Issuer is the user pool URL, https://cognito-idp.<region>.amazonaws.com/<pool id>.
+	Issuer string
+	// Audience lists the app client IDs accepted. Access tokens carry it in
+	// client_id, ID tokens in aud.
+	Audience []string
+	// TokenUse restricts tokens to "access" or "id"; empty accepts both.
+	TokenUse string
+	// Leeway tolerates clock skew on exp, nbf and iat.
+	Leeway time.Duration
+	// Revocations, when set, is consulted after all other checks passed.
+	Revocations RevocationChecker
+}
+
+type TokenValidator struct {
+	keySource jwks.KeySource
+	opts      ValidationOptions
+	parser    *jwt.Parser
+}
+
+func NewTokenValidator(keySource jwks.KeySource, opts ValidationOptions) *TokenValidator {
+	return &TokenValidator{
+		keySource: keySource,
+		opts:      opts,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(allowedSigningMethods),
+			jwt.WithLeeway(opts.Leeway),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+	}
+}
+
+// Validate verifies the signature and claims of tokenString. Failures are
+// reported as one of the ErrToken* errors, or jwks.ErrUnavailable when no key
+// set could be loaded at all.
+func (v *TokenValidator) Validate(ctx context.Context, tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, ErrTokenMalformed
+		}
+		key, err := v.keySource.LookupKeyID(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		var publicKey interface{}
+		if err := key.Raw(&publicKey); err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	})
+	if err != nil {
+		return nil, classifyParseError(err)
+	}
+
+	if v.opts.Issuer != "" && claims["iss"] != v.opts.Issuer {
+		return nil, ErrTokenInvalidIssuer
+	}
+
+	tokenUse, _ := claims["token_use"].(string)
+	if v.opts.TokenUse != "" && tokenUse != v.opts.TokenUse {
+		return nil, ErrTokenInvalidTokenUse
+	}
+
+	if len(v.opts.Audience) > 0 && !v.audienceAllowed(claims, tokenUse) {
+		return nil, ErrTokenInvalidAudience
+	}
+
+	sub, ok := claims["sub"].(string)
+	if !ok || sub == "" {
+		return nil, ErrTokenMissingSubject
+	}
+
+	if v.opts.Revocations != nil {
+		jti, _ := claims["jti"].(string)
+		var issuedAt time.Time
+		if iat, _ := claims.GetIssuedAt(); iat != nil {
+			issuedAt = iat.Time
+		}
+		if v.opts.Revocations.IsRevoked(jti, sub, issuedAt) {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
+
+func (v *TokenValidator) audienceAllowed(claims jwt.MapClaims, tokenUse string) bool {
+	var audiences []string
+	if tokenUse == TokenUseAccess {
+		clientID, _ := claims["client_id"].(string)
+		audiences = []string{clientID}
+	} else {
+		audiences, _ = claims.GetAudience()
+	}
+
+	for _, audience := range audiences {
+		for _, allowed := range v.opts.Audience {
+			if audience == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func classifyParseError(err error) error {
+	switch {
+	case errors.Is(err, jwks.ErrUnavailable):
+		return jwks.ErrUnavailable
+	case errors.Is(err, jwks.ErrKeyNotFound):
+		return ErrTokenUnknownKey
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrTokenNotYetValid
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return ErrTokenInvalidSignature
+	default:
+		return ErrTokenMalformed
+	}
+}
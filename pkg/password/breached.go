@@ -0,0 +1,78 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"sort"
+	"strings"
+)
+
+// BreachedList is a set of known breached passwords. Only the first 8 bytes
+// of each SHA-1 are kept, sorted, so a million entries take 8 MB and a false
+// positive is practically impossible.
+type BreachedList struct {
+	prefixes []uint64
+}
+
+// LoadBreachedList reads one entry per line: either the SHA-1 of a password
+// in hex, optionally followed by ":count" as in the Have I Been Pwned
+// downloads, or the password itself. Empty lines and lines starting with #
+// are skipped.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var prefixes []uint64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefixes = append(prefixes, linePrefix(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i] < prefixes[j] })
+	return &BreachedList{prefixes: prefixes}, nil
+}
+
+func linePrefix(line string) uint64 {
+	hash, _, _ := strings.Cut(line, ":")
+	if len(hash) == 2*sha1.Size {
+		if sum, err := hex.DecodeString(hash); err == nil {
+			return binary.BigEndian.Uint64(sum)
+		}
+	}
+	return prefix(line)
+}
+
+func prefix(password string) uint64 {
+	sum := sha1.Sum([]byte(password))
+	return binary.BigEndian.Uint64(sum[:])
+}
+
+// Contains reports whether password is on the list. A nil list contains nothing.
+func (l *BreachedList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+	want := prefix(password)
+	i := sort.Search(len(l.prefixes), func(i int) bool { return l.prefixes[i] >= want })
+	return i < len(l.prefixes) && l.prefixes[i] == want
+}
+
+func (l *BreachedList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.prefixes)
+}
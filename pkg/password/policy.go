@@ -0,0 +1,167 @@
+package password
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Cognito treats these as special characters in a password policy.
+const symbols = "^$*.[]{}()?\"!@#%&/\\,><':;|_~`=+- "
+
+// Rules checked by Checker; each Violation names one.
+const (
+	RuleLength    = "length"
+	RuleLowercase = "lowercase"
+	RuleUppercase = "uppercase"
+	RuleNumber    = "number"
+	RuleSymbol    = "symbol"
+	RuleStrength  = "strength"
+	RuleEmail     = "email"
+	RuleName      = "name"
+	RuleBreached  = "breached"
+)
+
+// Policy holds the length and character class rules of the user pool, plus
+// the strength score we require on top of them.
+type Policy struct {
+	MinimumLength    int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireNumbers   bool
+	RequireSymbols   bool
+	// MinScore is the lowest Score, from 0 to 4, a new password may have.
+	MinScore int
+}
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ClassViolation returns the first length or character class rule password
+// breaks, worded like Cognito's InvalidPasswordException.
+func (p Policy) ClassViolation(password string) (Violation, bool) {
+	if len([]rune(password)) < p.MinimumLength {
+		return Violation{RuleLength, "Password not long enough"}, true
+	}
+
+	var lower, upper, number, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			number = true
+		case strings.ContainsRune(symbols, r):
+			symbol = true
+		}
+	}
+
+	switch {
+	case p.RequireLowercase && !lower:
+		return Violation{RuleLowercase, "Password must have lowercase characters"}, true
+	case p.RequireUppercase && !upper:
+		return Violation{RuleUppercase, "Password must have uppercase characters"}, true
+	case p.RequireNumbers && !number:
+		return Violation{RuleNumber, "Password must have numeric characters"}, true
+	case p.RequireSymbols && !symbol:
+		return Violation{RuleSymbol, "Password must have symbol characters"}, true
+	default:
+		return Violation{}, false
+	}
+}
+
+// Result is the verdict on one password.
+type Result struct {
+	// Score grows from 0, trivially guessable, to 4, very strong.
+	Score      int
+	Violations []Violation
+}
+
+func (r Result) Acceptable() bool {
+	return len(r.Violations) == 0
+}
+
+// Checker evaluates passwords locally before they are sent to the identity
+// provider.
+type Checker struct {
+	policy   Policy
+	breached *BreachedList
+}
+
+// NewChecker builds a checker; a nil breached list skips that check.
+func NewChecker(policy Policy, breached *BreachedList) *Checker {
+	return &Checker{policy: policy, breached: breached}
+}
+
+// Check runs every rule against password. email and name belong to the
+// account and may be empty.
+func (c *Checker) Check(password, email, name string) Result {
+	var violations []Violation
+	if violation, ok := c.policy.ClassViolation(password); ok {
+		violations = append(violations, violation)
+	}
+
+	breached := c.breached.Contains(password)
+	if breached {
+		violations = append(violations, Violation{RuleBreached, "Password appears in a list of breached passwords"})
+	}
+
+	lower := strings.ToLower(password)
+	if containsAny(lower, emailTokens(email)) {
+		violations = append(violations, Violation{RuleEmail, "Password must not contain your email address"})
+	}
+	if containsAny(lower, words(name)) {
+		violations = append(violations, Violation{RuleName, "Password must not contain your name"})
+	}
+
+	// A breached password is guessable whatever its score; saying so twice
+	// doesn't help anyone.
+	score := 0
+	if !breached {
+		score = Score(password)
+	}
+	if !breached && score < c.policy.MinScore {
+		violations = append(violations, Violation{RuleStrength, "Password is too easy to guess"})
+	}
+
+	return Result{Score: score, Violations: violations}
+}
+
+// Tokens shorter than this match too many passwords by accident.
+const minTokenLength = 3
+
+func emailTokens(email string) []string {
+	local, _, _ := strings.Cut(email, "@")
+	tokens := words(local)
+	if len(local) >= minTokenLength {
+		tokens = append(tokens, strings.ToLower(local))
+	}
+	return tokens
+}
+
+// words splits s at anything but letters and digits.
+func words(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := fields[:0]
+	for _, field := range fields {
+		if len([]rune(field)) >= minTokenLength {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.Contains(s, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package password
+
+import (
+	"math"
+	"unicode"
+)
+
+// Entropy estimates the bits of entropy of password from the character
+// classes it draws on. Repeated and sequential characters, as in "aaa" or
+// "123", barely count.
+func Entropy(password string) float64 {
+	runes := []rune(password)
+
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r > unicode.MaxASCII:
+			other = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	length := 0.0
+	for i, r := range runes {
+		if i > 0 {
+			if delta := r - runes[i-1]; delta >= -1 && delta <= 1 {
+				length += 0.25
+				continue
+			}
+		}
+		length++
+	}
+	return length * math.Log2(float64(pool))
+}
+
+// Score maps Entropy onto 0 to 4, the scale password meters commonly show.
+func Score(password string) int {
+	bits := Entropy(password)
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 80:
+		return 3
+	default:
+		return 4
+	}
+}
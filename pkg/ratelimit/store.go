@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket: Requests tokens are refilled every Period and
+// at most Burst of them can be saved up. A zero Burst defaults to Requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Enabled reports whether the limit restricts anything at all.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// perMillisecond is the refill rate in tokens per millisecond.
+func (l Limit) perMillisecond() float64 {
+	return float64(l.Requests) / float64(l.Period.Milliseconds())
+}
+
+// Store keeps the buckets. Take removes one token from the bucket at key and
+// reports how long to wait for the next one when the bucket is empty.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryStore keeps buckets in process memory, so each replica enforces its
+// own limits. Use RedisStore to share them.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// full is when the bucket would be refilled completely and can be dropped.
+	full map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		full:    make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	now := time.Now()
+	burst := limit.burst()
+	rate := limit.perMillisecond()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := float64(now.Sub(b.last).Milliseconds())
+	b.tokens = math.Min(burst, b.tokens+math.Max(0, elapsed)*rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	var retryAfter time.Duration
+	if allowed {
+		b.tokens--
+	} else {
+		retryAfter = time.Duration(math.Ceil((1-b.tokens)/rate)) * time.Millisecond
+	}
+	s.full[key] = now.Add(time.Duration(math.Ceil((burst-b.tokens)/rate)) * time.Millisecond)
+
+	return allowed, retryAfter, nil
+}
+
+// Start drops refilled buckets until ctx is cancelled.
+func (s *MemoryStore) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.prune()
+			}
+		}
+	}()
+}
+
+func (s *MemoryStore) prune() {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, full := range s.full {
+		if now.After(full) {
+			delete(s.buckets, key)
+			delete(s.full, key)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cognito access tokens live at most a day, so nothing issued before a global
+// sign-out can still be valid after that.
+const DefaultMaxTokenLifetime = 24 * time.Hour
+
+// List remembers tokens that were logged out before they expired. Single
+// tokens are tracked by jti, global sign-outs by subject and time. The list
+// lives in process memory: every replica only knows the logouts it handled
+// and forgets them on restart, so behind a load balancer a logged-out token
+// stays usable on the other replicas until it expires.
+type List struct {
+	maxTokenLifetime time.Duration
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	subjects map[string]time.Time
+}
+
+func NewList(maxTokenLifetime time.Duration) *List {
+	if maxTokenLifetime <= 0 {
+		maxTokenLifetime = DefaultMaxTokenLifetime
+	}
+	return &List{
+		maxTokenLifetime: maxTokenLifetime,
+		tokens:           make(map[string]time.Time),
+		subjects:         make(map[string]time.Time),
+	}
+}
+
+// RevokeToken rejects the token with the given jti until it expires on its own.
+func (l *List) RevokeToken(jti string, expiresAt time.Time) {
+	if jti == "" || time.Now().After(expiresAt) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens[jti] = expiresAt
+}
+
+// RevokeSubject rejects every token of sub issued before the given time.
+// Tokens only carry whole seconds in iat, so the cutoff is truncated to the
+// second: a token from a login in the same second as the sign-out stays
+// valid rather than being rejected right after it was issued.
+func (l *List) RevokeSubject(sub string, issuedBefore time.Time) {
+	if sub == "" {
+		return
+	}
+	issuedBefore = issuedBefore.Truncate(time.Second)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if current, ok := l.subjects[sub]; !ok || issuedBefore.After(current) {
+		l.subjects[sub] = issuedBefore
+	}
+}
+
+func (l *List) IsRevoked(jti, sub string, issuedAt time.Time) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if _, ok := l.tokens[jti]; ok && jti != "" {
+		return true
+	}
+	if before, ok := l.subjects[sub]; ok && issuedAt.Before(before) {
+		return true
+	}
+	return false
+}
+
+// Start prunes entries that can no longer match a valid token until ctx is cancelled.
+func (l *List) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.prune(time.Now())
+			}
+		}
+	}()
+}
+
+func (l *List) prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for jti, expiresAt := range l.tokens {
+		if now.After(expiresAt) {
+			delete(l.tokens, jti)
+		}
+	}
+	for sub, before := range l.subjects {
+		if now.After(before.Add(l.maxTokenLifetime)) {
+			delete(l.subjects, sub)
+		}
+	}
+}
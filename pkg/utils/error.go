@@ -1,10 +1,43 @@
 package utils
 
+import "go.uber.org/zap"
+
 type CustomError struct {
 	Message string `json:"message"`
 	Status  int    `json:"status"`
+	// Code is stable across releases so clients can switch on it, see error_code.go.
+	Code string `json:"code"`
+	// RequestID is the AWS request ID of the call that failed, if any.
+	RequestID string `json:"request_id,omitempty"`
+	// Fields lists the request fields that failed validation, if any.
+	Fields []FieldError `json:"fields,omitempty"`
+	// Err is the underlying error; it is logged but never sent to clients.
+	Err error `json:"-"`
+}
+
+// FieldError is one validation rule a request field broke.
+type FieldError struct {
+	Field   string `json:"field" example:"password"`
+	Rule    string `json:"rule" example:"password"`
+	Message string `json:"message" example:"Password must have uppercase characters"`
 }
 
 func (e *CustomError) Error() string {
 	return e.Message
 }
+
+func (e *CustomError) Unwrap() error {
+	return e.Err
+}
+
+// LogFields describes the error for the logs, including what clients don't see.
+func (e *CustomError) LogFields() []zap.Field {
+	fields := []zap.Field{zap.String("error", e.Message), zap.String("code", e.Code)}
+	if e.RequestID != "" {
+		fields = append(fields, zap.String("aws_request_id", e.RequestID))
+	}
+	if e.Err != nil {
+		fields = append(fields, zap.NamedError("cause", e.Err))
+	}
+	return fields
+}
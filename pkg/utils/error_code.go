@@ -0,0 +1,79 @@
+package utils
+
+// Error codes returned next to the message. They are part of the API: never
+// change the value of an existing code, add a new one instead.
+const (
+	// Credentials and sessions
+	ErrCodeNotAuthorized          = "AUTH_NOT_AUTHORIZED"
+	ErrCodeIncorrectPassword      = "AUTH_INCORRECT_PASSWORD"
+	ErrCodeSessionExpired         = "AUTH_SESSION_EXPIRED"
+	ErrCodeTokenMissing           = "AUTH_TOKEN_MISSING"
+	ErrCodeTokenInvalid           = "AUTH_TOKEN_INVALID"
+	ErrCodeRefreshTokenInvalid    = "AUTH_REFRESH_TOKEN_INVALID"
+	ErrCodeRefreshSubjectRequired = "AUTH_REFRESH_SUBJECT_REQUIRED"
+	ErrCodeClientUnauthorized     = "AUTH_CLIENT_UNAUTHORIZED"
+	ErrCodeUnsupportedTokenType   = "AUTH_UNSUPPORTED_TOKEN_TYPE"
+	ErrCodeUnsupportedChallenge   = "AUTH_UNSUPPORTED_CHALLENGE"
+	ErrCodeForbidden              = "AUTH_FORBIDDEN"
+
+	// Users
+	ErrCodeUserNotFound          = "AUTH_USER_NOT_FOUND"
+	ErrCodeUserNotConfirmed      = "AUTH_USER_NOT_CONFIRMED"
+	ErrCodeUsernameExists        = "AUTH_USERNAME_EXISTS"
+	ErrCodeAliasExists           = "AUTH_ALIAS_EXISTS"
+	ErrCodeEmailInUse            = "AUTH_EMAIL_IN_USE"
+	ErrCodeEmailChangeDisabled   = "AUTH_EMAIL_CHANGE_DISABLED"
+	ErrCodeUnsupportedUserState  = "AUTH_UNSUPPORTED_USER_STATE"
+	ErrCodeUserImportInProgress  = "AUTH_USER_IMPORT_IN_PROGRESS"
+	ErrCodeNoAttributes          = "AUTH_NO_ATTRIBUTES"
+	ErrCodeAttributeNotWritable  = "AUTH_ATTRIBUTE_NOT_WRITABLE"
+	ErrCodePasswordResetRequired = "AUTH_PASSWORD_RESET_REQUIRED"
+
+	// Passwords
+	ErrCodePasswordInvalid          = "AUTH_PASSWORD_INVALID"
+	ErrCodePasswordTooShort         = "AUTH_PASSWORD_TOO_SHORT"
+	ErrCodePasswordMissingLowercase = "AUTH_PASSWORD_MISSING_LOWERCASE"
+	ErrCodePasswordMissingUppercase = "AUTH_PASSWORD_MISSING_UPPERCASE"
+	ErrCodePasswordMissingNumber    = "AUTH_PASSWORD_MISSING_NUMBER"
+	ErrCodePasswordMissingSymbol    = "AUTH_PASSWORD_MISSING_SYMBOL"
+	ErrCodePasswordReused           = "AUTH_PASSWORD_REUSED"
+
+	// Codes sent by email or SMS, and MFA
+	ErrCodeCodeMismatch           = "AUTH_CODE_MISMATCH"
+	ErrCodeCodeExpired            = "AUTH_CODE_EXPIRED"
+	ErrCodeCodeDeliveryFailed     = "AUTH_CODE_DELIVERY_FAILED"
+	ErrCodeMFACodeMismatch        = "AUTH_MFA_CODE_MISMATCH"
+	ErrCodeMFAMethodNotFound      = "AUTH_MFA_METHOD_NOT_FOUND"
+	ErrCodeSoftwareTokenNotFound  = "AUTH_SOFTWARE_TOKEN_NOT_FOUND"
+	ErrCodeTooManyFailedAttempts  = "AUTH_TOO_MANY_FAILED_ATTEMPTS"
+	ErrCodeTooManyRequests        = "AUTH_TOO_MANY_REQUESTS"
+	ErrCodeRateLimited            = "AUTH_RATE_LIMITED"
+	ErrCodeLimitExceeded          = "AUTH_LIMIT_EXCEEDED"
+	ErrCodeLambdaValidationFailed = "AUTH_LAMBDA_VALIDATION_FAILED"
+
+	// Groups and admin
+	ErrCodeGroupExists        = "AUTH_GROUP_EXISTS"
+	ErrCodeGroupNotFound      = "AUTH_GROUP_NOT_FOUND"
+	ErrCodeResourceNotFound   = "AUTH_RESOURCE_NOT_FOUND"
+	ErrCodeInvalidFilter      = "AUTH_INVALID_FILTER"
+	ErrCodeConflict           = "AUTH_CONFLICT"
+	ErrCodePreconditionFailed = "AUTH_PRECONDITION_FAILED"
+
+	// Hosted UI
+	ErrCodeRedirectURINotAllowed = "AUTH_REDIRECT_URI_NOT_ALLOWED"
+	ErrCodeInvalidState          = "AUTH_INVALID_STATE"
+	ErrCodeInvalidGrant          = "AUTH_INVALID_GRANT"
+	ErrCodeOAuthError            = "AUTH_OAUTH_ERROR"
+
+	// Requests and the identity provider itself
+	ErrCodeInvalidRequest     = "AUTH_INVALID_REQUEST"
+	ErrCodeValidationFailed   = "AUTH_VALIDATION_FAILED"
+	ErrCodeNotFound           = "AUTH_NOT_FOUND"
+	ErrCodeInvalidParameter   = "AUTH_INVALID_PARAMETER"
+	ErrCodeIdPMisconfigured   = "AUTH_IDP_MISCONFIGURED"
+	ErrCodeIdPUnavailable     = "AUTH_IDP_UNAVAILABLE"
+	ErrCodeIdPTimeout         = "AUTH_IDP_TIMEOUT"
+	ErrCodeLambdaFailed       = "AUTH_LAMBDA_FAILED"
+	ErrCodeUnexpectedResponse = "AUTH_UNEXPECTED_RESPONSE"
+	ErrCodeInternal           = "AUTH_INTERNAL_ERROR"
+)